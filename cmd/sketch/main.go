@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/lestrrat-go/sketch/gen"
@@ -10,7 +9,8 @@ import (
 func main() {
 	var app gen.App
 	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		// errors are written to the log output, so that --quiet suppresses them
+		app.Printf("%s", err.Error())
 		os.Exit(1)
 	}
 }
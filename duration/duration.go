@@ -0,0 +1,115 @@
+// Package duration provides a storage type for time.Duration values that
+// are serialized into JSON as Go duration strings (e.g. "1h30m").
+//
+// It is used by `schema.DurationType`, but may be used on its own as well.
+package duration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Duration stores a time.Duration value. When encoded into JSON, the
+// value is serialized as a string in the format produced by
+// `time.Duration.String`.
+type Duration struct {
+	d time.Duration
+}
+
+// New creates a new Duration from a time.Duration value
+func New(d time.Duration) *Duration {
+	return &Duration{d: d}
+}
+
+// AcceptValue assigns the value to Duration. The following types are accepted:
+//
+//   - time.Duration and *time.Duration
+//   - numeric values (float64, int, int64, json.Number), treated as nanoseconds.
+//     Values with a fractional part are rejected.
+//   - strings accepted by `time.ParseDuration`. The empty string is treated
+//     as the zero value of time.Duration
+//   - nil, which is treated as the zero value of time.Duration
+func (d *Duration) AcceptValue(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		d.d = 0
+	case time.Duration:
+		d.d = v
+	case *time.Duration:
+		if v == nil {
+			d.d = 0
+			return nil
+		}
+		d.d = *v
+	case int:
+		d.d = time.Duration(v)
+	case int64:
+		d.d = time.Duration(v)
+	case float64:
+		if v != math.Trunc(v) || v >= math.MaxInt64 || v < math.MinInt64 {
+			return fmt.Errorf(`invalid number of nanoseconds for duration.Duration (got %v)`, v)
+		}
+		d.d = time.Duration(v)
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return fmt.Errorf(`failed to parse %q as nanoseconds: %w`, v.String(), err)
+		}
+		d.d = time.Duration(i)
+	case string:
+		if v == "" {
+			d.d = 0
+			return nil
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf(`failed to parse %q as duration: %w`, v, err)
+		}
+		d.d = parsed
+	default:
+		return fmt.Errorf(`invalid value for duration.Duration (got %T)`, v)
+	}
+	return nil
+}
+
+// GetValue returns the time.Duration value. The zero value is returned
+// when d is nil.
+func (d *Duration) GetValue() time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.d
+}
+
+// Clone returns a copy of d
+func (d *Duration) Clone() *Duration {
+	if d == nil {
+		return nil
+	}
+	return &Duration{d: d.d}
+}
+
+// Equal returns true if both values represent the same duration
+func (d *Duration) Equal(other *Duration) bool {
+	return d.GetValue() == other.GetValue()
+}
+
+// MarshalJSON encodes the value as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, d.d.String()), nil
+}
+
+// UnmarshalJSON decodes the value using the same rules as `AcceptValue`
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf(`failed to decode duration.Duration: %w`, err)
+	}
+	return d.AcceptValue(v)
+}
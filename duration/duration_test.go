@@ -0,0 +1,59 @@
+package duration_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/sketch/duration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptValue(t *testing.T) {
+	expected := 90 * time.Minute
+	testcases := []struct {
+		Name     string
+		Value    interface{}
+		Expected time.Duration
+		Error    bool
+	}{
+		{Name: `time.Duration`, Value: expected, Expected: expected},
+		{Name: `int64`, Value: int64(expected), Expected: expected},
+		{Name: `float64`, Value: float64(expected), Expected: expected},
+		{Name: `json.Number`, Value: json.Number(`5400000000000`), Expected: expected},
+		{Name: `string`, Value: `1h30m`, Expected: expected},
+		{Name: `empty string`, Value: ``, Expected: 0},
+		{Name: `nil`, Value: nil, Expected: 0},
+		{Name: `fractional nanoseconds`, Value: float64(1.5), Error: true},
+		{Name: `invalid string`, Value: `forever`, Error: true},
+		{Name: `invalid type`, Value: true, Error: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			var v duration.Duration
+			err := v.AcceptValue(tc.Value)
+			if tc.Error {
+				require.Error(t, err, `v.AcceptValue should fail`)
+				return
+			}
+			require.NoError(t, err, `v.AcceptValue should succeed`)
+			require.Equal(t, tc.Expected, v.GetValue(), `values should match`)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	v := duration.New(90 * time.Minute)
+	buf, err := json.Marshal(v)
+	require.NoError(t, err, `json.Marshal should succeed`)
+	require.Equal(t, `"1h30m0s"`, string(buf))
+
+	var decoded duration.Duration
+	require.NoError(t, json.Unmarshal(buf, &decoded), `json.Unmarshal should succeed`)
+	require.True(t, v.Equal(&decoded), `values should match`)
+
+	require.NoError(t, json.Unmarshal([]byte(`5400000000000`), &decoded), `json.Unmarshal should succeed`)
+	require.True(t, v.Equal(&decoded), `values should match`)
+}
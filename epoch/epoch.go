@@ -0,0 +1,109 @@
+// Package epoch provides a storage type for time.Time values that
+// are serialized into JSON as the number of seconds since the Unix epoch.
+//
+// It is used by `schema.TimeType`, but may be used on its own as well.
+package epoch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Time stores a time.Time value. When encoded into JSON, the value is
+// serialized as an integer representing the number of seconds since
+// the Unix epoch. Sub-second precision is therefore lost.
+type Time struct {
+	t time.Time
+}
+
+// New creates a new Time from a time.Time value
+func New(t time.Time) *Time {
+	return &Time{t: t}
+}
+
+// AcceptValue assigns the value to Time. The following types are accepted:
+//
+//   - time.Time and *time.Time
+//   - numeric values (float64, int, int64, json.Number), treated as seconds since the Unix epoch.
+//     Fractional seconds are preserved.
+//   - strings in RFC3339 format
+//   - nil, which is treated as the zero value of time.Time
+func (t *Time) AcceptValue(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		t.t = time.Time{}
+	case time.Time:
+		t.t = v
+	case *time.Time:
+		if v == nil {
+			t.t = time.Time{}
+			return nil
+		}
+		t.t = *v
+	case int:
+		t.t = time.Unix(int64(v), 0)
+	case int64:
+		t.t = time.Unix(v, 0)
+	case float64:
+		sec, frac := math.Modf(v)
+		t.t = time.Unix(int64(sec), int64(frac*1e9))
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			t.t = time.Unix(i, 0)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf(`failed to parse %q as epoch: %w`, v.String(), err)
+		}
+		return t.AcceptValue(f)
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf(`failed to parse %q as RFC3339: %w`, v, err)
+		}
+		t.t = parsed
+	default:
+		return fmt.Errorf(`invalid value for epoch.Time (got %T)`, v)
+	}
+	return nil
+}
+
+// GetValue returns the time.Time value. The zero value is returned
+// when t is nil.
+func (t *Time) GetValue() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.t
+}
+
+// Clone returns a copy of t
+func (t *Time) Clone() *Time {
+	if t == nil {
+		return nil
+	}
+	return &Time{t: t.t}
+}
+
+// Equal returns true if both values represent the same instant
+func (t *Time) Equal(other *Time) bool {
+	return t.GetValue().Equal(other.GetValue())
+}
+
+// MarshalJSON encodes the value as the number of seconds since the Unix epoch
+func (t Time) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, t.t.Unix(), 10), nil
+}
+
+// UnmarshalJSON decodes the value using the same rules as `AcceptValue`
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf(`failed to decode epoch.Time: %w`, err)
+	}
+	return t.AcceptValue(v)
+}
@@ -0,0 +1,54 @@
+package epoch_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/sketch/epoch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptValue(t *testing.T) {
+	expected := time.Unix(1665000000, 0)
+	testcases := []struct {
+		Name     string
+		Value    interface{}
+		Expected time.Time
+		Error    bool
+	}{
+		{Name: `time.Time`, Value: expected, Expected: expected},
+		{Name: `int64`, Value: int64(1665000000), Expected: expected},
+		{Name: `float64`, Value: float64(1665000000.5), Expected: expected.Add(500 * time.Millisecond)},
+		{Name: `json.Number`, Value: json.Number(`1665000000`), Expected: expected},
+		{Name: `RFC3339`, Value: expected.UTC().Format(time.RFC3339), Expected: expected},
+		{Name: `nil`, Value: nil, Expected: time.Time{}},
+		{Name: `invalid string`, Value: `yesterday`, Error: true},
+		{Name: `invalid type`, Value: true, Error: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			var v epoch.Time
+			err := v.AcceptValue(tc.Value)
+			if tc.Error {
+				require.Error(t, err, `v.AcceptValue should fail`)
+				return
+			}
+			require.NoError(t, err, `v.AcceptValue should succeed`)
+			require.True(t, tc.Expected.Equal(v.GetValue()), `values should match (%s != %s)`, tc.Expected, v.GetValue())
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	v := epoch.New(time.Unix(1665000000, 0))
+	buf, err := json.Marshal(v)
+	require.NoError(t, err, `json.Marshal should succeed`)
+	require.Equal(t, `1665000000`, string(buf))
+
+	var decoded epoch.Time
+	require.NoError(t, json.Unmarshal(buf, &decoded), `json.Unmarshal should succeed`)
+	require.True(t, v.Equal(&decoded), `values should match`)
+}
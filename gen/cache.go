@@ -0,0 +1,210 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// compilerCacheKey computes the key used to look up the compiler
+// generated in ctx.tmpDir from the cache directory. It must be called
+// before the compiler is built, as building modifies the contents of
+// the temporary directory.
+//
+// The key covers everything that affects the generated code:
+// the sketch executable itself (and therefore the version of sketch and
+// its templates), the source code of the compiler, the variables passed
+// to the templates including those specified via --var, the Go source
+// files of the schema package and of the packages in the same module
+// that it depends on, the go.mod/go.sum files of the module containing
+// the schema package, and the source tree specified via --dev-path
+func compilerCacheKey(ctx *genCtx) (string, error) {
+	h := sha256.New()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf(`failed to find the sketch executable: %w`, err)
+	}
+	if err := hashFile(h, `exe`, exe); err != nil {
+		return "", err
+	}
+
+	err = filepath.WalkDir(ctx.tmpDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(ctx.tmpDir, path)
+		if err != nil {
+			return fmt.Errorf(`failed to get relative path from %q to %q: %w`, ctx.tmpDir, path, err)
+		}
+		return hashFile(h, `compiler/`+filepath.ToSlash(rel), path)
+	})
+	if err != nil {
+		return "", fmt.Errorf(`failed to compute hash for compiler: %w`, err)
+	}
+
+	// maps are encoded with sorted keys, so the encoded value is stable
+	vars, err := json.Marshal(ctx.variables)
+	if err != nil {
+		return "", fmt.Errorf(`failed to encode variables: %w`, err)
+	}
+	hashEntry(h, `variables`, vars)
+
+	entries, err := os.ReadDir(ctx.srcDir)
+	if err != nil {
+		return "", fmt.Errorf(`failed to read directory %q: %w`, ctx.srcDir, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), `.go`) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := hashFile(h, `schema/`+name, filepath.Join(ctx.srcDir, name)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := hashLocalDeps(ctx, h); err != nil {
+		return "", err
+	}
+
+	if moduleDir, _ := ctx.variables[`SrcModulePath`].(string); moduleDir != "" {
+		for _, name := range []string{`go.mod`, `go.sum`} {
+			fn := filepath.Join(moduleDir, name)
+			if _, err := os.Stat(fn); err != nil {
+				continue
+			}
+			if err := hashFile(h, `module/`+name, fn); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if devPath, _ := ctx.variables[`DevPath`].(string); devPath != "" {
+		if err := hashTree(h, `dev/`, devPath); err != nil {
+			return "", fmt.Errorf(`failed to compute hash for %q: %w`, devPath, err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashLocalDeps hashes the source files of the packages that the schema
+// package depends on, and that belong to the main module. Packages from
+// other modules are covered by go.sum. Errors in resolving the packages
+// are ignored, as they are reported when the compiler is built
+func hashLocalDeps(ctx *genCtx, h hash.Hash) error {
+	dir := ctx.srcDir
+	cmd := ctx.goCommand(dir, `list`, `-e`, `-mod=readonly`, `-deps`,
+		`-f`, `{{ if (and .Module .Module.Main) }}{{ .ImportPath }}{{ "\t" }}{{ .Dir }}{{ range .GoFiles }}{{ "\t" }}{{ . }}{{ end }}{{ range .EmbedFiles }}{{ "\t" }}{{ . }}{{ end }}{{ "\n" }}{{ end }}`,
+		`.`)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf(`failed to list dependencies of %q: %w`, dir, err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		for _, name := range fields[2:] {
+			if err := hashFile(h, `deps/`+fields[0]+`/`+name, filepath.Join(fields[1], name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hashTree hashes all files under root, skipping hidden directories
+// such as .git
+func hashTree(h hash.Hash, prefix, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), `.`) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf(`failed to get relative path from %q to %q: %w`, root, path, err)
+		}
+		return hashFile(h, prefix+filepath.ToSlash(rel), path)
+	})
+}
+
+// hashEntry writes a named entry to h. The name and the length of
+// the data are included, so that the boundaries between entries
+// cannot be confused
+func hashEntry(h hash.Hash, name string, data []byte) {
+	fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+	h.Write(data)
+}
+
+func hashFile(h hash.Hash, name, fn string) error {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return fmt.Errorf(`failed to read file %q: %w`, fn, err)
+	}
+	hashEntry(h, name, data)
+	return nil
+}
+
+// storeCompiler copies the compiler built at src to dst. The file is
+// first written under a temporary name and then renamed, so that
+// concurrent runs never see an incomplete compiler
+func storeCompiler(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf(`failed to create directory %q: %w`, filepath.Dir(dst), err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf(`failed to open file %q for reading: %w`, src, err)
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dst), `.sketch-compiler-*`)
+	if err != nil {
+		return fmt.Errorf(`failed to create temporary file: %w`, err)
+	}
+	defer os.Remove(out.Name())
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf(`failed to copy compiler to %q: %w`, out.Name(), err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf(`failed to close file %q: %w`, out.Name(), err)
+	}
+	if err := os.Chmod(out.Name(), 0755); err != nil {
+		return fmt.Errorf(`failed to change permissions of %q: %w`, out.Name(), err)
+	}
+	if err := os.Rename(out.Name(), dst); err != nil {
+		return fmt.Errorf(`failed to rename %q to %q: %w`, out.Name(), dst, err)
+	}
+	return nil
+}
@@ -5,15 +5,20 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -25,19 +30,67 @@ import (
 //go:embed tmpl/*
 var embedded embed.FS
 
+// LogLevel controls which messages are written by App
+type LogLevel int
+
+const (
+	// LogLevelQuiet suppresses all messages, including errors
+	LogLevelQuiet LogLevel = iota - 1
+	// LogLevelDefault writes errors, including the output of the tools
+	// that fail while generating code, and the progress of --watch
+	LogLevelDefault
+	// LogLevelVerbose additionally writes the progress of each step
+	LogLevelVerbose
+)
+
 type App struct {
 	excludedSchemaRegexps []*regexp.Regexp
-	verbose               bool
+	logOutput             io.Writer
+	logLevel              LogLevel
+}
+
+// SetLogOutput specifies where messages are written (default: os.Stderr).
+// It must be called before Run.
+func (app *App) SetLogOutput(w io.Writer) {
+	app.logOutput = w
+}
+
+// SetLogLevel specifies which messages are written (default: LogLevelDefault).
+// It must be called before Run. The level is overridden by --verbose and --quiet.
+func (app *App) SetLogLevel(level LogLevel) {
+	app.logLevel = level
+}
+
+// logWriter returns the writer that messages of the given level are
+// written to, which discards them if the level is not enabled
+func (app *App) logWriter(level LogLevel) io.Writer {
+	if app.logLevel < level {
+		return io.Discard
+	}
+	if app.logOutput == nil {
+		return os.Stderr
+	}
+	return app.logOutput
+}
+
+// Printf writes a message unless the log level is LogLevelQuiet
+func (app *App) Printf(f string, args ...interface{}) {
+	app.logf(LogLevelDefault, f, args...)
 }
 
+// Infof writes a message if the log level is LogLevelVerbose
 func (app *App) Infof(f string, args ...interface{}) {
-	if !app.verbose {
+	app.logf(LogLevelVerbose, f, args...)
+}
+
+func (app *App) logf(level LogLevel, f string, args ...interface{}) {
+	if app.logLevel < level {
 		return
 	}
 	if !strings.HasPrefix(f, "\n") {
 		f += "\n"
 	}
-	fmt.Fprintf(os.Stdout, f, args...)
+	fmt.Fprintf(app.logWriter(level), f, args...)
 }
 
 func (app *App) DumpJSON(v interface{}) {
@@ -52,6 +105,11 @@ func (app *App) DumpJSON(v interface{}) {
 }
 
 type genCtx struct {
+	cacheDir  string
+	diff      bool
+	dryRun    bool
+	goProxy   string
+	modMode   string
 	srcDir    string
 	usrDirs   []string
 	dstDir    string
@@ -60,15 +118,72 @@ type genCtx struct {
 }
 
 func (app *App) Run(args []string) error {
-	cliapp := cli.App{
-		// cmd <schema_dir> -tmpl-dir=<dir1> -dst-dir=<dir>
+	return app.newCLI().Run(args)
+}
+
+func (app *App) newCLI() *cli.App {
+	return &cli.App{
+		// cmd <schema_dir> [<schema_dir>...] -tmpl-dir=<dir1> -dst-dir=<dir>
 		Name:   "sketch",
 		Usage:  "Generate code from schema",
 		Action: app.RunMain,
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "read default values for flags from `FILE` (default: sketch.yml, sketch.yaml, or sketch.json in the schema directory, if only one is given)",
+			},
 			&cli.BoolFlag{
 				Name:  "verbose",
-				Usage: "Output verbose logging to stdout",
+				Usage: "Output verbose logging to stderr",
+			},
+			&cli.BoolFlag{
+				Name:  "quiet",
+				Usage: "suppress all logging, including errors",
+			},
+			&cli.BoolFlag{
+				Name:  "diff",
+				Usage: "generate files in the temporary directory, and print the differences against the files in the destination directory instead of writing them. Exits with an error if there are differences",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "generate files in the temporary directory, and report the files that would have been written instead of writing them",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "watch the schema directories, and regenerate the code whenever they change",
+			},
+			&cli.BoolFlag{
+				Name:  "list-schemas",
+				Usage: "print the schemas and their fields as a table instead of generating code",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "format generated files using `FORMATTER` (gofmt, goimports, or none). goimports falls back to gofmt when it is not available",
+				Value: "gofmt",
+			},
+			&cli.StringFlag{
+				Name:  "header",
+				Usage: "insert `TEXT` at the beginning of each generated file, e.g. a license notice. Lines that are not comments are prefixed with \"//\"",
+			},
+			&cli.StringFlag{
+				Name:  "header-file",
+				Usage: "same as --header, but reads the text from `FILE`",
+			},
+			&cli.BoolFlag{
+				Name:  "emit-go-generate",
+				Usage: "add a //go:generate directive to sketch_gen.go, which reproduces the generated code using the same options when `go generate` is run",
+			},
+			&cli.StringFlag{
+				Name:  "cache-dir",
+				Usage: "reuse compilers stored in `DIR`, and store newly built compilers there. The compiler is only rebuilt when the schemas, variables, or the version of sketch change",
+			},
+			&cli.StringFlag{
+				Name:  "goproxy",
+				Usage: "set GOPROXY to `VALUE` when running the go command to build the compiler (e.g. \"off\" to only use the module cache)",
+			},
+			&cli.StringFlag{
+				Name:  "mod-mode",
+				Usage: "build the compiler with -mod=`MODE` (mod, readonly, or vendor). With vendor, the dependencies are copied into the temporary directory before building",
 			},
 			&cli.BoolFlag{
 				Name:  "remove-tmpdir",
@@ -87,14 +202,163 @@ func (app *App) Run(args []string) error {
 				Name:  "dev-path",
 				Usage: "path to the sketch source code (default: current dir)",
 			},
+			&cli.StringFlag{
+				Name:  "accessor-style",
+				Usage: "use `STYLE` for field accessors. \"plain\" returns zero values for unset fields, \"comma-ok\" additionally generates GetXXX() accessors that return (value, ok)",
+				Value: "plain",
+			},
+			&cli.StringFlag{
+				Name:  "accessor-prefix",
+				Usage: "prepend `PREFIX` to the names of field accessors. The default \"Get\" generates GetXXX(), while an empty prefix generates XXX(). When --accessor-style=comma-ok is specified, the default is empty",
+				Value: "Get",
+			},
+			&cli.BoolFlag{
+				Name:  "with-graphql",
+				Usage: "generate constants containing the GraphQL type definitions of the objects",
+			},
+			&cli.BoolFlag{
+				Name:  "with-has-methods",
+				Usage: "generate HasXXX() methods for each field. Individual fields may override this via FieldSpec.HasMethod",
+				Value: true,
+			},
+			&cli.BoolFlag{
+				Name:  "with-interface",
+				Usage: "generate an interface containing the accessor methods for each object",
+			},
+			&cli.BoolFlag{
+				Name:  "with-jsonschema",
+				Usage: "generate JSON Schema documents describing the JSON representation of the objects",
+			},
+			&cli.BoolFlag{
+				Name:  "with-openapi",
+				Usage: "generate OpenAPI 3 component schemas named xxx_openapi_gen.json for each object",
+			},
 			&cli.BoolFlag{
 				Name:  "with-key-name-prefix",
 				Usage: "prepend object names in key name constant variables",
 			},
+			&cli.StringFlag{
+				Name:  "key-name-suffix",
+				Usage: "append `SUFFIX` to the names of key name constant variables. May be empty",
+				Value: "Key",
+			},
+			&cli.BoolFlag{
+				Name:  "builders-same-file",
+				Usage: "generate builders in the same file as the objects, instead of a separate xxx_builder_gen.go file",
+			},
+			&cli.BoolFlag{
+				Name:  "struct-tags",
+				Usage: "emit json struct tags, and the tags specified via FieldSpec.Tag, on the fields of the generated structs",
+			},
+			&cli.BoolFlag{
+				Name:  "walk-skip-secret",
+				Usage: "generate Walk() methods that do not visit fields marked as secret",
+			},
+			&cli.BoolFlag{
+				Name:  "with-asmap",
+				Usage: "generate AsMap() methods that return the populated fields as a map",
+			},
+			&cli.BoolFlag{
+				Name:  "with-binary",
+				Usage: "generate MarshalBinary()/UnmarshalBinary() methods",
+			},
+			&cli.StringFlag{
+				Name:  "binary-format",
+				Usage: "use `FORMAT` for MarshalBinary() methods. \"json\" uses the JSON representation, \"gob\" encodes each populated field using encoding/gob",
+				Value: "json",
+			},
+			&cli.BoolFlag{
+				Name:  "with-cbor",
+				Usage: "generate MarshalCBOR()/UnmarshalCBOR() methods",
+			},
+			&cli.BoolFlag{
+				Name:  "cbor-deterministic",
+				Usage: "generate MarshalCBOR() methods that use deterministic encoding (sorted map keys)",
+			},
+			&cli.BoolFlag{
+				Name:  "with-clone",
+				Usage: "generate Clone() methods that create deep copies",
+			},
+			&cli.BoolFlag{
+				Name:  "with-constructor",
+				Usage: "generate a constructor that takes the required fields as arguments for each object",
+			},
+			&cli.BoolFlag{
+				Name:  "with-diff",
+				Usage: "generate Diff() methods that list the fields that differ between two objects",
+			},
+			&cli.BoolFlag{
+				Name:  "with-equal",
+				Usage: "generate Equal() methods that compare objects field by field",
+			},
+			&cli.BoolFlag{
+				Name:  "with-form",
+				Usage: "generate EncodeForm()/DecodeForm() methods that convert objects to and from url.Values",
+			},
+			&cli.BoolFlag{
+				Name:  "with-gob",
+				Usage: "generate GobEncode()/GobDecode() methods, so that objects can be encoded with encoding/gob",
+			},
+			&cli.BoolFlag{
+				Name:  "with-logmarshal",
+				Usage: "generate MarshalZerologObject() methods for logging objects with github.com/rs/zerolog",
+			},
+			&cli.BoolFlag{
+				Name:  "with-merge",
+				Usage: "generate Merge() methods that copy populated fields from another object",
+			},
+			&cli.BoolFlag{
+				Name:  "with-msgpack",
+				Usage: "generate EncodeMsgpack()/DecodeMsgpack() methods",
+			},
+			&cli.BoolFlag{
+				Name:  "with-options",
+				Usage: "generate functional options and a constructor for each object",
+			},
+			&cli.BoolFlag{
+				Name:  "with-ptr-accessors",
+				Usage: "generate XXXPtr() methods and builder methods that take pointers to the values of optional fields",
+			},
+			&cli.BoolFlag{
+				Name:  "with-registry",
+				Usage: "generate a registry of constructors for all objects, keyed by their names",
+			},
+			&cli.BoolFlag{
+				Name:  "with-reset",
+				Usage: "generate Reset() methods that return objects to their unset state",
+			},
+			&cli.BoolFlag{
+				Name:  "with-sql",
+				Usage: "generate Scan()/Value() methods for use with database/sql",
+			},
+			&cli.BoolFlag{
+				Name:  "with-strict-json",
+				Usage: "generate UnmarshalJSON() methods that reject keys not declared in the schema",
+			},
+			&cli.BoolFlag{
+				Name:  "with-stringer",
+				Usage: "generate String() methods that redact secret fields",
+			},
+			&cli.BoolFlag{
+				Name:  "with-toml",
+				Usage: "generate MarshalTOML()/UnmarshalTOML() methods",
+			},
+			&cli.BoolFlag{
+				Name:  "with-validation",
+				Usage: "generate Validate() methods that check field constraints",
+			},
+			&cli.BoolFlag{
+				Name:  "with-xml",
+				Usage: "generate MarshalXML()/UnmarshalXML() methods",
+			},
+			&cli.BoolFlag{
+				Name:  "with-yaml",
+				Usage: "generate MarshalYAML()/UnmarshalYAML() methods",
+			},
 			&cli.StringFlag{
 				Name:    `dst-dir`,
 				Aliases: []string{"d"},
-				Usage:   "use `DIR` as destination to write generated files (default: current directory)",
+				Usage:   "use `DIR` as destination to write generated files (default: current directory, or the parent of each schema directory when multiple are given)",
 			},
 			&cli.StringSliceFlag{
 				Name:    "tmpl-dir",
@@ -105,9 +369,13 @@ func (app *App) Run(args []string) error {
 				Name:  "exclude-schema",
 				Usage: "Regular expression to match against schema names. If they match the schema will not be processed.",
 			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-field",
+				Usage: "Regular expression to match against field names. If they match the field will not be generated. If schemas define their own GenerateField, these patterns will be ignored",
+			},
 			&cli.StringSliceFlag{
 				Name:  "exclude-symbol",
-				Usage: "Regular expression to match against symbol names. If they match the method will not be generated. If schemas define their own GenerateSymbol, these patterns will be ignored",
+				Usage: "Regular expression to match against symbol names. If they match the method will not be generated. These patterns are applied even if schemas define their own GenerateSymbol",
 			},
 			&cli.StringSliceFlag{
 				Name:  "rename-symbol",
@@ -115,8 +383,6 @@ func (app *App) Run(args []string) error {
 			},
 		},
 	}
-
-	return cliapp.Run(args)
 }
 
 type DeclaredSchema struct {
@@ -124,88 +390,403 @@ type DeclaredSchema struct {
 }
 
 var reMajorVersion = regexp.MustCompile(`v\d+$`)
-var reMatchVar = regexp.MustCompile(`([^=]+)=(.+)(?::(bool|string|int))?`)
+
+// reMatchVar matches NAME=VALUE, optionally followed by :TYPE. The value
+// is matched non-greedily and the pattern is anchored, so that the type
+// suffix is only recognized at the very end of the declaration, while
+// values may contain colons themselves (e.g. URLs)
+var reMatchVar = regexp.MustCompile(`^([^=]+)=(.+?)(?::(bool|string|int))?$`)
+
+// reIdentifier matches valid Go identifiers, which is what names that
+// are given from the command line (e.g. accessor prefixes) must be
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
 func (app *App) RunMain(c *cli.Context) error {
 	// Prepare the context
-	if c.NArg() != 1 {
+	if c.NArg() < 1 {
 		cli.ShowAppHelp(c)
-		return fmt.Errorf(`schema directory must be supplied`)
+		return fmt.Errorf(`at least one schema directory must be supplied`)
 	}
 
-	app.verbose = c.Bool(`verbose`)
+	cfgVars, err := app.loadConfig(c)
+	if err != nil {
+		return err
+	}
 
-	variables := make(map[string]interface{})
-	if vars := c.StringSlice(`var`); len(vars) > 0 {
-		for _, sv := range vars {
-			matches := reMatchVar.FindAllStringSubmatch(sv, -1)
-			if len(matches) == 0 {
-				return fmt.Errorf(`invalid variable declaration %q`, sv)
-			}
+	switch {
+	case c.Bool(`verbose`) && c.Bool(`quiet`):
+		return fmt.Errorf(`--verbose and --quiet cannot be specified at the same time`)
+	case c.Bool(`verbose`):
+		app.logLevel = LogLevelVerbose
+	case c.Bool(`quiet`):
+		app.logLevel = LogLevelQuiet
+	}
 
-			name := matches[0][1]
-			typ := matches[0][3]
+	opts, err := optionsFromCLI(c, cfgVars)
+	if err != nil {
+		return err
+	}
 
-			switch typ {
-			case "", "string":
-				variables[name] = matches[0][2]
-			case "int":
-				i, err := strconv.ParseInt(matches[0][2], 10, 64)
-				if err != nil {
-					return fmt.Errorf(`failed to parse %q as bool: %w`, name, err)
-				}
-				variables[name] = i
-			case "bool":
-				b, err := strconv.ParseBool(matches[0][2])
-				if err != nil {
-					return fmt.Errorf(`failed to parse %q as bool: %w`, name, err)
-				}
-				variables[name] = b
-			default:
-				return fmt.Errorf(`unhandled variable type %q for %q`, typ, name)
+	if c.Bool(`list-schemas`) {
+		if c.Bool(`watch`) || opts.Diff || opts.DryRun {
+			return fmt.Errorf(`list-schemas cannot be used in conjunction with watch, diff, or dry-run`)
+		}
+		return app.ListSchemas(os.Stdout, opts)
+	}
+
+	if !c.Bool(`watch`) {
+		return app.Generate(opts)
+	}
+
+	if opts.Diff || opts.DryRun {
+		return fmt.Errorf(`watch cannot be used in conjunction with diff or dry-run`)
+	}
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+	defer stop()
+	return app.watch(ctx, opts.SrcDirs, func() error {
+		return app.Generate(opts)
+	})
+}
+
+// optionsFromCLI creates the Options from the command line flags.
+// Variables declared in the configuration file are used as the initial
+// values, and are overridden by the values specified via --var
+func optionsFromCLI(c *cli.Context, cfgVars map[string]interface{}) (Options, error) {
+	opts := Options{
+		SrcDirs:            c.Args().Slice(),
+		DstDir:             c.String(`dst-dir`),
+		TemplateDirs:       c.StringSlice(`tmpl-dir`),
+		Variables:          make(map[string]interface{}),
+		ExcludeSchemas:     c.StringSlice(`exclude-schema`),
+		ExcludeSymbols:     c.StringSlice(`exclude-symbol`),
+		ExcludeFields:      c.StringSlice(`exclude-field`),
+		RenameSymbols:      make(map[string]string),
+		AccessorStyle:      c.String(`accessor-style`),
+		AccessorPrefix:     c.String(`accessor-prefix`),
+		KeyNameSuffix:      c.String(`key-name-suffix`),
+		Format:             c.String(`format`),
+		Header:             c.String(`header`),
+		BinaryFormat:       c.String(`binary-format`),
+		GenerateHasMethods: c.Bool(`with-has-methods`),
+		WithKeyNamePrefix:  c.Bool(`with-key-name-prefix`),
+		BuildersSameFile:   c.Bool(`builders-same-file`),
+		StructTags:         c.Bool(`struct-tags`),
+		WalkSkipSecret:     c.Bool(`walk-skip-secret`),
+		CBORDeterministic:  c.Bool(`cbor-deterministic`),
+		WithAsMap:          c.Bool(`with-asmap`),
+		WithBinary:         c.Bool(`with-binary`),
+		WithCBOR:           c.Bool(`with-cbor`),
+		WithClone:          c.Bool(`with-clone`),
+		WithConstructor:    c.Bool(`with-constructor`),
+		WithDiff:           c.Bool(`with-diff`),
+		WithEqual:          c.Bool(`with-equal`),
+		WithForm:           c.Bool(`with-form`),
+		WithGob:            c.Bool(`with-gob`),
+		WithGraphQL:        c.Bool(`with-graphql`),
+		WithInterface:      c.Bool(`with-interface`),
+		WithJSONSchema:     c.Bool(`with-jsonschema`),
+		WithLogMarshal:     c.Bool(`with-logmarshal`),
+		WithMerge:          c.Bool(`with-merge`),
+		WithMsgpack:        c.Bool(`with-msgpack`),
+		WithOpenAPI:        c.Bool(`with-openapi`),
+		WithOptions:        c.Bool(`with-options`),
+		WithPtrAccessors:   c.Bool(`with-ptr-accessors`),
+		WithRegistry:       c.Bool(`with-registry`),
+		WithReset:          c.Bool(`with-reset`),
+		WithSQL:            c.Bool(`with-sql`),
+		WithStrictJSON:     c.Bool(`with-strict-json`),
+		WithStringer:       c.Bool(`with-stringer`),
+		WithTOML:           c.Bool(`with-toml`),
+		WithValidation:     c.Bool(`with-validation`),
+		WithXML:            c.Bool(`with-xml`),
+		WithYAML:           c.Bool(`with-yaml`),
+		CacheDir:           c.String(`cache-dir`),
+		GoProxy:            c.String(`goproxy`),
+		ModMode:            c.String(`mod-mode`),
+		Diff:               c.Bool(`diff`),
+		DryRun:             c.Bool(`dry-run`),
+		KeepTmpDir:         !c.Bool(`remove-tmpdir`),
+	}
+
+	for name, value := range cfgVars {
+		opts.Variables[name] = value
+	}
+	for _, sv := range c.StringSlice(`var`) {
+		matches := reMatchVar.FindAllStringSubmatch(sv, -1)
+		if len(matches) == 0 {
+			return opts, fmt.Errorf(`invalid variable declaration %q`, sv)
+		}
+
+		name := matches[0][1]
+		typ := matches[0][3]
+
+		switch typ {
+		case "", "string":
+			opts.Variables[name] = matches[0][2]
+		case "int":
+			i, err := strconv.ParseInt(matches[0][2], 10, 64)
+			if err != nil {
+				return opts, fmt.Errorf(`failed to parse %q as int: %w`, name, err)
 			}
+			opts.Variables[name] = i
+		case "bool":
+			b, err := strconv.ParseBool(matches[0][2])
+			if err != nil {
+				return opts, fmt.Errorf(`failed to parse %q as bool: %w`, name, err)
+			}
+			opts.Variables[name] = b
+		default:
+			return opts, fmt.Errorf(`unhandled variable type %q for %q`, typ, name)
 		}
 	}
-	variables["Verbose"] = app.verbose
 
-	renames := make(map[string]string)
 	for _, pair := range c.StringSlice(`rename-symbol`) {
 		kv := strings.Split(pair, "=")
-		renames[kv[0]] = kv[1]
+		opts.RenameSymbols[kv[0]] = kv[1]
 	}
-	variables["Renames"] = renames
 
-	if patterns := c.StringSlice(`exclude-symbol`); len(patterns) > 0 {
-		variables["Excludes"] = patterns
+	if opts.AccessorStyle == "comma-ok" && !c.IsSet(`accessor-prefix`) {
+		// GetXXX() is taken by the comma-ok accessors
+		opts.AccessorPrefix = ""
+	}
+
+	if filename := c.String(`header-file`); filename != "" {
+		if opts.Header != "" {
+			return opts, fmt.Errorf(`--header and --header-file cannot be specified at the same time`)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return opts, fmt.Errorf(`failed to read header file %q: %w`, filename, err)
+		}
+		opts.Header = string(data)
+	}
+
+	if c.Bool(`emit-go-generate`) {
+		opts.GoGenerate = func(srcDir, dstDir string) (string, error) {
+			return goGenerateDirective(c, srcDir, dstDir)
+		}
 	}
 
-	if patterns := c.StringSlice(`exclude-schema`); len(patterns) > 0 {
-		app.excludedSchemaRegexps = make([]*regexp.Regexp, len(patterns))
-		for i, pattern := range patterns {
-			rx, err := regexp.Compile(pattern)
+	if c.Bool(`dev-mode`) {
+		opts.DevPath = c.String(`dev-path`)
+		if opts.DevPath == "" {
+			wd, err := os.Getwd()
 			if err != nil {
-				return fmt.Errorf(`failed to compile pattern %q for exclude-schema: %w`, pattern, err)
+				return opts, fmt.Errorf(`failed to compute working directory: %w`, err)
 			}
-			app.excludedSchemaRegexps[i] = rx
+			opts.DevPath = wd
 		}
 	}
+	return opts, nil
+}
 
-	srcDir := c.Args().Get(0)
+// Generate generates the code for the schemas in each of the schema
+// directories specified in opts. Unlike Run, it does not read the
+// command line flags or the configuration file.
+func (app *App) Generate(opts Options) error {
+	if len(opts.SrcDirs) == 0 {
+		return fmt.Errorf(`at least one schema directory must be supplied`)
+	}
+	if len(opts.SrcDirs) > 1 && opts.DstDir != "" {
+		return fmt.Errorf(`dst-dir cannot be specified when multiple schema directories are supplied`)
+	}
+	if err := app.prepare(opts); err != nil {
+		return err
+	}
 
-	app.Infof(`👉 Accepted src directory %q`, srcDir)
-	// srcDir must be absolute
-	absSrcDir, err := filepath.Abs(srcDir)
+	variables, err := app.makeVariables(opts)
 	if err != nil {
-		return fmt.Errorf(`failed to get absolute path for %q: %w`, srcDir, err)
+		return err
+	}
+
+	var outOfDate bool
+	for _, srcDir := range opts.SrcDirs {
+		app.Infof(`👉 Accepted src directory %q`, srcDir)
+		// srcDir must be absolute
+		absSrcDir, err := filepath.Abs(srcDir)
+		if err != nil {
+			return fmt.Errorf(`failed to get absolute path for %q: %w`, srcDir, err)
+		}
+		if srcDir != absSrcDir {
+			app.Infof(`   ✅ Converted src directory to %q`, absSrcDir)
+		}
+
+		// When multiple schema directories are given, the generated files
+		// are written to the parent directory of each schema directory
+		dstDir := opts.DstDir
+		if len(opts.SrcDirs) > 1 {
+			dstDir = filepath.Dir(absSrcDir)
+		}
+
+		if err := app.generate(opts, variables, absSrcDir, dstDir); err != nil {
+			// keep going, so that differences for all directories are reported
+			if errors.Is(err, errOutOfDate) {
+				outOfDate = true
+				continue
+			}
+			return fmt.Errorf(`failed to generate code for schema directory %q: %w`, srcDir, err)
+		}
+	}
+
+	if outOfDate {
+		return errOutOfDate
+	}
+	return nil
+}
+
+// prepare validates the options that are common to Generate and
+// ListSchemas, and compiles the patterns of the excluded schemas
+func (app *App) prepare(opts Options) error {
+	switch opts.ModMode {
+	case "", "mod", "readonly", "vendor":
+	default:
+		return fmt.Errorf(`invalid module mode %q (must be "mod", "readonly", or "vendor")`, opts.ModMode)
+	}
+
+	app.excludedSchemaRegexps = make([]*regexp.Regexp, len(opts.ExcludeSchemas))
+	for i, pattern := range opts.ExcludeSchemas {
+		rx, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf(`failed to compile pattern %q for exclude-schema: %w`, pattern, err)
+		}
+		app.excludedSchemaRegexps[i] = rx
 	}
-	if srcDir != absSrcDir {
-		app.Infof(`   ✅ Converted src directory to %q`, absSrcDir)
+	return nil
+}
+
+// makeVariables creates the variables that are passed to the templates
+// from the options
+func (app *App) makeVariables(opts Options) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+	for name, value := range opts.Variables {
+		variables[name] = value
 	}
-	srcDir = absSrcDir
+	variables["Verbose"] = app.logLevel >= LogLevelVerbose
 
-	dstDir := c.String(`dst-dir`)
+	renames := make(map[string]string)
+	for from, to := range opts.RenameSymbols {
+		renames[from] = to
+	}
+	variables["Renames"] = renames
+
+	if len(opts.ExcludeSymbols) > 0 {
+		variables["Excludes"] = opts.ExcludeSymbols
+	}
+
+	if len(opts.ExcludeFields) > 0 {
+		variables["ExcludeFields"] = opts.ExcludeFields
+	}
+
+	var usrDirs []string
+	for _, usrDir := range opts.TemplateDirs {
+		abs, err := filepath.Abs(usrDir)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to get absolute path for %q: %w`, usrDir, err)
+		}
+		usrDirs = append(usrDirs, abs)
+	}
+
+	variables[`UserTemplateDirs`] = usrDirs
+	switch style := opts.AccessorStyle; style {
+	case "", "plain":
+		variables[`AccessorStyle`] = "plain"
+	case "comma-ok":
+		variables[`AccessorStyle`] = style
+	default:
+		return nil, fmt.Errorf(`invalid accessor style %q (must be "plain" or "comma-ok")`, style)
+	}
+	if prefix := opts.AccessorPrefix; prefix != "" && !reIdentifier.MatchString(prefix) {
+		return nil, fmt.Errorf(`invalid accessor prefix %q (must be a valid Go identifier)`, prefix)
+	}
+	variables[`AccessorPrefix`] = opts.AccessorPrefix
+	switch formatter := opts.Format; formatter {
+	case "", "gofmt":
+		variables[`Format`] = "gofmt"
+	case "goimports", "none":
+		variables[`Format`] = formatter
+	default:
+		return nil, fmt.Errorf(`invalid formatter %q (must be "gofmt", "goimports", or "none")`, formatter)
+	}
+	if strings.TrimSpace(opts.Header) != "" {
+		variables[`Header`] = commentHeader(opts.Header)
+	}
+	variables[`GenerateHasMethods`] = opts.GenerateHasMethods
+	variables[`WithGraphQL`] = opts.WithGraphQL
+	variables[`WithInterface`] = opts.WithInterface
+	variables[`WithJSONSchema`] = opts.WithJSONSchema
+	variables[`WithOpenAPI`] = opts.WithOpenAPI
+	variables[`WithKeyNamePrefix`] = opts.WithKeyNamePrefix
+	variables[`KeyNameSuffix`] = opts.KeyNameSuffix
+	variables[`BuildersSameFile`] = opts.BuildersSameFile
+	variables[`StructTags`] = opts.StructTags
+	variables[`WalkSkipSecret`] = opts.WalkSkipSecret
+	variables[`WithAsMap`] = opts.WithAsMap
+	variables[`WithBinary`] = opts.WithBinary
+	switch format := opts.BinaryFormat; format {
+	case "", "json":
+		variables[`BinaryFormat`] = "json"
+	case "gob":
+		variables[`BinaryFormat`] = format
+	default:
+		return nil, fmt.Errorf(`invalid binary format %q (must be "json" or "gob")`, format)
+	}
+	variables[`WithCBOR`] = opts.WithCBOR
+	variables[`CBORDeterministic`] = opts.CBORDeterministic
+	variables[`WithClone`] = opts.WithClone
+	variables[`WithConstructor`] = opts.WithConstructor
+	variables[`WithDiff`] = opts.WithDiff
+	variables[`WithEqual`] = opts.WithEqual
+	variables[`WithForm`] = opts.WithForm
+	variables[`WithGob`] = opts.WithGob
+	variables[`WithLogMarshal`] = opts.WithLogMarshal
+	variables[`WithMerge`] = opts.WithMerge
+	variables[`WithMsgpack`] = opts.WithMsgpack
+	variables[`WithOptions`] = opts.WithOptions
+	variables[`WithPtrAccessors`] = opts.WithPtrAccessors
+	variables[`WithRegistry`] = opts.WithRegistry
+	variables[`WithReset`] = opts.WithReset
+	variables[`WithSQL`] = opts.WithSQL
+	variables[`WithStrictJSON`] = opts.WithStrictJSON
+	variables[`WithStringer`] = opts.WithStringer
+	variables[`WithTOML`] = opts.WithTOML
+	variables[`WithValidation`] = opts.WithValidation
+	variables[`WithXML`] = opts.WithXML
+	variables[`WithYAML`] = opts.WithYAML
+	if opts.DevPath != "" {
+		variables[`DevPath`] = opts.DevPath
+	}
+	return variables, nil
+}
+
+// commentHeader converts the text specified via --header or --header-file
+// into a block of Go comments, followed by an empty line so that it is not
+// mistaken as the package documentation. Lines that are not comments yet
+// are prefixed with "//". Block comments are used as is.
+func commentHeader(text string) string {
+	text = strings.TrimRight(text, " \t\r\n")
+	if strings.HasPrefix(strings.TrimSpace(text), `/*`) {
+		return text + "\n\n"
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), `//`):
+		case line == "":
+			line = `//`
+		default:
+			line = `// ` + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n") + "\n\n"
+}
+
+// generate generates code for the schemas declared in a single
+// schema directory. srcDir must be an absolute path.
+func (app *App) generate(opts Options, globals map[string]interface{}, srcDir, dstDir string) error {
 	if dstDir == "" {
-		panic("WHY WHY WHY")
 		dir, err := os.Getwd()
 		if err != nil {
 			return fmt.Errorf(`failed to compute current working directory: %w`, err)
@@ -224,7 +805,7 @@ func (app *App) RunMain(c *cli.Context) error {
 		return fmt.Errorf(`failed to create temporary directory: %w`, err)
 	}
 	defer func() {
-		if !c.Bool(`remove-tmpdir`) {
+		if opts.KeepTmpDir {
 			app.Infof(`👉 NOT removing temporary working directory %q`, tmpDir)
 			return
 		}
@@ -234,74 +815,35 @@ func (app *App) RunMain(c *cli.Context) error {
 	}()
 	app.Infof(`👉 Created temporary working directory %q`, tmpDir)
 
-	var moduleDir string
-	var gomodFn string
-	for srcDir := srcDir; len(srcDir) > 0; {
-		gomodFn = filepath.Join(srcDir, `go.mod`)
-		if _, err := os.Stat(gomodFn); err == nil {
-			moduleDir = srcDir
-			break
-		}
-		srcDirComps := strings.Split(srcDir, sepStr)
-		srcDirComps = srcDirComps[:len(srcDirComps)-1]
-		srcDir = strings.Join(srcDirComps, sepStr)
-	}
-
-	if moduleDir == "" {
-		return fmt.Errorf(`failed to find go.mod`)
-	}
-
-	app.Infof(`👉 Accepted module directory %q`, moduleDir)
-
-	gomodContent, err := os.ReadFile(gomodFn)
-	if err != nil {
-		return fmt.Errorf(`failed to read from %q: %w`, gomodFn, err)
-	}
-
-	parsedMod, err := modfile.Parse(gomodFn, gomodContent, nil)
+	variables, err := app.moduleVariables(globals, srcDir)
 	if err != nil {
-		return fmt.Errorf(`failed to parse %q: %w`, gomodFn, err)
-	}
-
-	schemaDir, err := filepath.Rel(moduleDir, srcDir)
-	if err != nil {
-		return fmt.Errorf(`failed to get relative path from %q to %q: %w`, moduleDir, srcDir, err)
+		return err
 	}
 
-	var usrDirs []string
-	for _, usrDir := range c.StringSlice(`tmpl-dir`) {
-		abs, err := filepath.Abs(usrDir)
+	if opts.GoGenerate != nil {
+		directive, err := opts.GoGenerate(srcDir, dstDir)
 		if err != nil {
-			return fmt.Errorf(`failed to get absolute path for %q: %w`, usrDir, err)
+			return fmt.Errorf(`failed to compute go:generate directive: %w`, err)
 		}
-		usrDirs = append(usrDirs, abs)
+		variables[`GoGenerate`] = directive
 	}
 
-	srcModule := parsedMod.Module.Mod.Path
-	srcModuleVersion := "v0.0.0"
-	if majorV := reMajorVersion.FindString(srcModule); majorV != "" {
-		srcModuleVersion = majorV + ".0.0"
-	}
-
-	variables[`SrcModule`] = srcModule
-	variables[`SrcModulePath`] = moduleDir
-	variables[`SrcModuleVersion`] = srcModuleVersion
-	variables[`SrcPkg`] = filepath.Clean(filepath.Join(parsedMod.Module.Mod.Path, schemaDir))
-	variables[`UserTemplateDirs`] = usrDirs
-	variables[`WithKeyNamePrefix`] = c.Bool(`with-key-name-prefix`)
-	if c.Bool(`dev-mode`) {
-		devpath := c.String(`dev-path`)
-		if devpath == "" {
-			wd, err := os.Getwd()
-			if err != nil {
-				return fmt.Errorf(`failed to compute working directory: %w`, err)
-			}
-			devpath = wd
+	var cacheDir string
+	if dir := opts.CacheDir; dir != "" {
+		absCacheDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf(`failed to get absolute path for %q: %w`, dir, err)
 		}
-		variables[`DevPath`] = devpath
+		cacheDir = absCacheDir
 	}
 
+	usrDirs, _ := variables[`UserTemplateDirs`].([]string)
 	ctx := genCtx{
+		cacheDir:  cacheDir,
+		diff:      opts.Diff,
+		dryRun:    opts.DryRun,
+		goProxy:   opts.GoProxy,
+		modMode:   opts.ModMode,
 		srcDir:    srcDir,
 		dstDir:    dstDir,
 		tmpDir:    tmpDir,
@@ -315,7 +857,7 @@ func (app *App) RunMain(c *cli.Context) error {
 	}
 
 	if len(schemas) == 0 {
-		return fmt.Errorf(`could not find any suitable objects to generate code for`)
+		return app.errNoSchemas(srcDir)
 	}
 
 	// Using these schemas, we dynamically generate some source code
@@ -324,24 +866,109 @@ func (app *App) RunMain(c *cli.Context) error {
 		return err
 	}
 
-	if err := app.buildCompiler(&ctx); err != nil {
+	compiler, err := app.buildCompiler(&ctx)
+	if err != nil {
 		return fmt.Errorf(`failed to build compiler: %w`, err)
 	}
 
+	if err := app.runCompiler(&ctx, compiler); err != nil {
+		return fmt.Errorf(`failed to run sketch compiler: %w`, err)
+	}
+
 	return nil
 }
 
+// moduleVariables returns a copy of globals, to which the variables
+// describing the module containing srcDir are added. The variables are
+// copied, as each schema directory may belong to a different module.
+// srcDir must be an absolute path.
+func (app *App) moduleVariables(globals map[string]interface{}, srcDir string) (map[string]interface{}, error) {
+	var moduleDir string
+	var gomodFn string
+	for dir := srcDir; len(dir) > 0; {
+		gomodFn = filepath.Join(dir, `go.mod`)
+		if _, err := os.Stat(gomodFn); err == nil {
+			moduleDir = dir
+			break
+		}
+		dirComps := strings.Split(dir, sepStr)
+		dirComps = dirComps[:len(dirComps)-1]
+		dir = strings.Join(dirComps, sepStr)
+	}
+
+	if moduleDir == "" {
+		return nil, fmt.Errorf(`failed to find go.mod`)
+	}
+
+	app.Infof(`👉 Accepted module directory %q`, moduleDir)
+
+	gomodContent, err := os.ReadFile(gomodFn)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to read from %q: %w`, gomodFn, err)
+	}
+
+	parsedMod, err := modfile.Parse(gomodFn, gomodContent, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to parse %q: %w`, gomodFn, err)
+	}
+
+	schemaDir, err := filepath.Rel(moduleDir, srcDir)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to get relative path from %q to %q: %w`, moduleDir, srcDir, err)
+	}
+
+	srcModule := parsedMod.Module.Mod.Path
+	srcModuleVersion := "v0.0.0"
+	if majorV := reMajorVersion.FindString(srcModule); majorV != "" {
+		srcModuleVersion = majorV + ".0.0"
+	}
+
+	variables := make(map[string]interface{}, len(globals)+4)
+	for k, v := range globals {
+		variables[k] = v
+	}
+	variables[`SrcModule`] = srcModule
+	variables[`SrcModulePath`] = moduleDir
+	variables[`SrcModuleVersion`] = srcModuleVersion
+	variables[`SrcPkg`] = filepath.Clean(filepath.Join(parsedMod.Module.Mod.Path, schemaDir))
+	return variables, nil
+}
+
 func (app *App) extractStructs(ctx *genCtx) ([]*DeclaredSchema, error) {
 	dir := ctx.srcDir
 	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+	// Test files are not part of the package that the compiler imports,
+	// so they are ignored (including those in a separate _test package)
+	isSource := func(fi fs.FileInfo) bool {
+		name := fi.Name()
+		return !fi.IsDir() && strings.HasSuffix(name, `.go`) && !strings.HasSuffix(name, `_test.go`)
+	}
+	pkgs, err := parser.ParseDir(fset, dir, isSource, parser.AllErrors)
 	if err != nil {
-		return nil, err
+		// report all errors with their positions, instead of
+		// just the first one
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			msgs := make([]string, len(list))
+			for i, e := range list {
+				msgs[i] = e.Error()
+			}
+			return nil, fmt.Errorf("failed to parse schema files in %q:\n  %s", dir, strings.Join(msgs, "\n  "))
+		}
+		return nil, fmt.Errorf(`failed to parse schema files in %q: %w`, dir, err)
+	}
+
+	if len(pkgs) > 1 {
+		names := make([]string, 0, len(pkgs))
+		for name := range pkgs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf(`found multiple packages in %q (%s): schema directories must contain exactly one package`, dir, strings.Join(names, `, `))
 	}
 
 	var schemas []*DeclaredSchema
 	for _, pkg := range pkgs {
-		// There should be only one package
 		for _, file := range pkg.Files {
 			schemaPkg := "schema"
 			for _, imp := range file.Imports {
@@ -354,6 +981,21 @@ func (app *App) extractStructs(ctx *genCtx) ([]*DeclaredSchema, error) {
 
 			for _, node := range file.Decls {
 				switch node := node.(type) {
+				case *ast.FuncDecl:
+					// A package level TemplateFuncs() function provides
+					// extra functions for the templates
+					if node.Recv == nil && node.Name.Name == `TemplateFuncs` {
+						ctx.variables[`HasTemplateFuncs`] = true
+					}
+					// A package level PostGenerate(dir string[, files []string]) error
+					// function is called after all files have been written
+					if node.Recv == nil && node.Name.Name == `PostGenerate` {
+						n := node.Type.Params.NumFields()
+						if n != 1 && n != 2 {
+							return nil, fmt.Errorf(`PostGenerate in %q must be declared as "func PostGenerate(dir string) error" or "func PostGenerate(dir string, files []string) error"`, dir)
+						}
+						ctx.variables[`PostGenerateArgs`] = n
+					}
 				case *ast.GenDecl:
 					for _, spec := range node.Specs {
 						switch spec := spec.(type) {
@@ -378,6 +1020,15 @@ func (app *App) extractStructs(ctx *genCtx) ([]*DeclaredSchema, error) {
 	return schemas, nil
 }
 
+// errNoSchemas returns the error reported when no schemas are found in srcDir
+func (app *App) errNoSchemas(srcDir string) error {
+	var hint string
+	if len(app.excludedSchemaRegexps) > 0 {
+		hint = ` (some of them may have been excluded via --exclude-schema)`
+	}
+	return fmt.Errorf(`could not find any schemas in %q: schemas must be struct types that embed schema.Base from package "github.com/lestrrat-go/sketch/schema"%s`, srcDir, hint)
+}
+
 func (app *App) isSchemaAllowed(name string) bool {
 	for _, rx := range app.excludedSchemaRegexps {
 		if rx.MatchString(name) {
@@ -418,8 +1069,21 @@ func (app *App) genCompiler(ctx *genCtx, schemas []*DeclaredSchema) error {
 	// Copy files
 	toCopy := []string{
 		"tmpl/builder.tmpl",
+		"tmpl/interface.tmpl",
 		"tmpl/object.tmpl",
 	}
+	// The registry is generated as a separate file, so the template
+	// is only made available when requested
+	if withRegistry, _ := ctx.variables[`WithRegistry`].(bool); withRegistry {
+		toCopy = append(toCopy, "tmpl/registry.tmpl")
+	}
+	// Likewise, JSON Schema documents are only generated when requested
+	if withJSONSchema, _ := ctx.variables[`WithJSONSchema`].(bool); withJSONSchema {
+		toCopy = append(toCopy, "tmpl/jsonschema.tmpl")
+	}
+	if withOpenAPI, _ := ctx.variables[`WithOpenAPI`].(bool); withOpenAPI {
+		toCopy = append(toCopy, "tmpl/openapi.tmpl")
+	}
 	for _, name := range toCopy {
 		to := filepath.Join(ctx.tmpDir, name)
 		dir := filepath.Dir(to)
@@ -512,7 +1176,21 @@ func (app *App) generateCompilerMain(ctx *genCtx, tmpl *template.Template, schem
 	return nil
 }
 
-func (app *App) buildCompiler(ctx *genCtx) error {
+// goCommand creates a command that runs the go command in dir. The
+// environment, including GOFLAGS, is inherited, except that GOPROXY is
+// overridden when --goproxy is specified
+func (ctx *genCtx) goCommand(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	if ctx.goProxy != "" {
+		cmd.Env = append(os.Environ(), "GOPROXY="+ctx.goProxy)
+	}
+	return cmd
+}
+
+// buildCompiler builds the compiler generated under the temporary
+// directory, or reuses the one in the cache, and returns its path
+func (app *App) buildCompiler(ctx *genCtx) (string, error) {
 	dumpMain := func() {
 		f, err := os.Open(filepath.Join(ctx.tmpDir, "main.go"))
 		if err == nil {
@@ -521,38 +1199,133 @@ func (app *App) buildCompiler(ctx *genCtx) error {
 			scanner := bufio.NewScanner(f)
 			i := 1
 			for scanner.Scan() {
-				fmt.Fprintf(os.Stderr, "%04d: %s\n", i, scanner.Text())
+				fmt.Fprintf(app.logWriter(LogLevelDefault), "%04d: %s\n", i, scanner.Text())
 				i++
 			}
 		}
 	}
 
-	app.Infof(`👉 Running "go mod tidy"`)
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = ctx.tmpDir
-	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
-	if err := cmd.Run(); err != nil {
-		dumpMain()
-		return fmt.Errorf(`failed to run go mod tidy: %w`, err)
+	compiler := filepath.Join(ctx.tmpDir, `sketch-compiler`)
+
+	// The key must be computed before running "go mod tidy",
+	// which modifies the contents of the temporary directory
+	var cached string
+	if ctx.cacheDir != "" {
+		key, err := compilerCacheKey(ctx)
+		if err != nil {
+			return "", fmt.Errorf(`failed to compute cache key: %w`, err)
+		}
+		cached = filepath.Join(ctx.cacheDir, `sketch-compiler-`+key)
 	}
 
-	app.Infof(`👉 Running "go build -o sketch-compiler"`)
-	cmd = exec.Command("go", "build", "-o", "sketch-compiler")
-	cmd.Dir = ctx.tmpDir
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		dumpMain()
-		return fmt.Errorf(`failed to run go build: %w`, err)
+	if fi, err := os.Stat(cached); cached != "" && err == nil && fi.Mode().IsRegular() {
+		app.Infof(`👉 Using cached compiler %q`, cached)
+		compiler = cached
+	} else {
+		app.Infof(`👉 Running "go mod tidy"`)
+		cmd := ctx.goCommand(ctx.tmpDir, "mod", "tidy")
+		cmd.Stderr = app.logWriter(LogLevelDefault)
+		cmd.Stdout = app.logWriter(LogLevelVerbose)
+		if err := cmd.Run(); err != nil {
+			dumpMain()
+			return "", fmt.Errorf(`failed to run go mod tidy: %w`, err)
+		}
+
+		if ctx.modMode == "vendor" {
+			app.Infof(`👉 Running "go mod vendor"`)
+			cmd = ctx.goCommand(ctx.tmpDir, "mod", "vendor")
+			cmd.Stderr = app.logWriter(LogLevelDefault)
+			if err := cmd.Run(); err != nil {
+				return "", fmt.Errorf(`failed to run go mod vendor: %w`, err)
+			}
+		}
+
+		args := []string{"build", "-o", "sketch-compiler"}
+		if ctx.modMode != "" {
+			args = append(args, "-mod="+ctx.modMode)
+		}
+		app.Infof(`👉 Running "go %s"`, strings.Join(args, " "))
+		cmd = ctx.goCommand(ctx.tmpDir, args...)
+		cmd.Stderr = app.logWriter(LogLevelDefault)
+		if err := cmd.Run(); err != nil {
+			dumpMain()
+			return "", fmt.Errorf(`failed to run go build: %w`, err)
+		}
+
+		if cached != "" {
+			app.Infof(`👉 Storing compiler in %q`, cached)
+			if err := storeCompiler(compiler, cached); err != nil {
+				return "", fmt.Errorf(`failed to store compiler in cache: %w`, err)
+			}
+		}
+	}
+	return compiler, nil
+}
+
+// runCompiler runs the compiler, which writes the generated files to the
+// destination directory, or to a temporary directory when the files are
+// only reported or compared
+func (app *App) runCompiler(ctx *genCtx, compiler string) error {
+	args := []string{ctx.dstDir}
+	var writeDir string
+	if ctx.dryRun || ctx.diff {
+		// Files are written under the temporary directory, so that
+		// nothing under the destination directory is touched
+		writeDir = filepath.Join(ctx.tmpDir, `out`)
+		if err := os.MkdirAll(writeDir, 0755); err != nil {
+			return fmt.Errorf(`failed to create directory %q: %w`, writeDir, err)
+		}
+		args = append(args, writeDir)
 	}
 
 	app.Infof(`👉 Running "./sketch-compiler"`)
-	cmd = exec.Command("./sketch-compiler", ctx.dstDir)
+	cmd := exec.Command(compiler, args...)
 	cmd.Dir = ctx.tmpDir
-	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
+	cmd.Stderr = app.logWriter(LogLevelDefault)
+	cmd.Stdout = app.logWriter(LogLevelVerbose)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf(`failed to run go build:%w`, err)
+		return err
+	}
+
+	if ctx.dryRun {
+		if err := reportDryRun(os.Stdout, writeDir, ctx.dstDir); err != nil {
+			return err
+		}
+	}
+
+	if ctx.diff {
+		found, err := diffGenerated(os.Stdout, writeDir, ctx.dstDir)
+		if err != nil {
+			return fmt.Errorf(`failed to compare generated files: %w`, err)
+		}
+		if found {
+			return errOutOfDate
+		}
 	}
 	return nil
 }
+
+// reportDryRun prints the files generated under writeDir, as if they
+// were written under dstDir
+func reportDryRun(dst io.Writer, writeDir, dstDir string) error {
+	return filepath.WalkDir(writeDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf(`failed to stat %q: %w`, path, err)
+		}
+
+		rel, err := filepath.Rel(writeDir, path)
+		if err != nil {
+			return fmt.Errorf(`failed to get relative path from %q to %q: %w`, writeDir, path, err)
+		}
+		fmt.Fprintf(dst, "%s (%d bytes)\n", filepath.Join(dstDir, rel), info.Size())
+		return nil
+	})
+}
@@ -0,0 +1,3803 @@
+package gen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/sketch/gen"
+	"github.com/stretchr/testify/require"
+)
+
+// newSketchModule creates a module named example.com/sketchtest that
+// contains schemaSrc as schema.go, and returns its directory. Tests that
+// generate code build the compiler, so they are skipped in short mode
+func newSketchModule(t *testing.T, schemaSrc string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip(`skipping test that builds the compiler in short mode`)
+	}
+	if _, err := exec.LookPath(`go`); err != nil {
+		t.Skip(`go command is not available`)
+	}
+
+	srcDir := t.TempDir()
+	writeFile(t, filepath.Join(srcDir, `go.mod`), "module example.com/sketchtest\n\ngo 1.18\n")
+	writeFile(t, filepath.Join(srcDir, `schema.go`), schemaSrc)
+	return srcDir
+}
+
+func writeFile(t *testing.T, fn, src string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755), `os.MkdirAll should succeed`)
+	require.NoError(t, os.WriteFile(fn, []byte(src), 0644), `writing %s should succeed`, filepath.Base(fn))
+}
+
+// runSketchApp runs sketch in development mode, generating code from
+// the schema in srcDir into dstDir
+func runSketchApp(t *testing.T, srcDir, dstDir string, args ...string) error {
+	t.Helper()
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+
+	args = append([]string{`sketch`, `--dev-mode`, `--dev-path`, devPath, `--dst-dir`, dstDir}, args...)
+	var app gen.App
+	return app.Run(append(args, srcDir))
+}
+
+// runSketch generates code from the schema in srcDir into the package
+// example.com/sketchtest/out, and returns the directory of the package
+func runSketch(t *testing.T, srcDir string, args ...string) string {
+	t.Helper()
+	dstDir := filepath.Join(srcDir, `out`)
+	require.NoError(t, runSketchApp(t, srcDir, dstDir, args...), `app.Run should succeed`)
+	return dstDir
+}
+
+// runSketchFailure is like runSketch, but expects code generation to fail.
+// Template errors are reported by the compiler via stderr, which is
+// returned along with the directory of the package. The error returned
+// by app.Run is appended to the output
+func runSketchFailure(t *testing.T, srcDir string, args ...string) (string, string) {
+	t.Helper()
+	stderr, err := os.Create(filepath.Join(t.TempDir(), `stderr`))
+	require.NoError(t, err, `os.Create should succeed`)
+	defer stderr.Close()
+	origStderr := os.Stderr
+	os.Stderr = stderr
+	defer func() { os.Stderr = origStderr }()
+
+	dstDir := filepath.Join(srcDir, `out`)
+	err = runSketchApp(t, srcDir, dstDir, args...)
+	os.Stderr = origStderr
+	require.Error(t, err, `app.Run should fail`)
+	runErr := err
+
+	output, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err, `reading stderr should succeed`)
+	return dstDir, string(output) + runErr.Error()
+}
+
+// testGenerated adds a test file to the generated package in dstDir,
+// and runs go vet, with the given flags, and its tests. The modules
+// that the generated code depends on are added to the schema module
+// as they are found
+func testGenerated(t *testing.T, dstDir, name, src string, vetFlags ...string) {
+	t.Helper()
+	writeFile(t, filepath.Join(dstDir, name), src)
+
+	cmd := exec.Command(`go`, append(append([]string{`vet`, `-mod=mod`}, vetFlags...), `.`)...)
+	cmd.Dir = dstDir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, `generated code should pass go vet: %s`, out)
+
+	cmd = exec.Command(`go`, `test`, `-mod=mod`, `.`)
+	cmd.Dir = dstDir
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, `generated code should pass the tests: %s`, out)
+}
+
+func TestRunMainDefaultDstDir(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err, `os.Getwd should succeed`)
+	// the package name of the generated code is derived from the directory name
+	dstDir := filepath.Join(t.TempDir(), `sketchtest`)
+	require.NoError(t, os.Mkdir(dstDir, 0755), `os.Mkdir should succeed`)
+	require.NoError(t, os.Chdir(dstDir), `os.Chdir should succeed`)
+	defer os.Chdir(wd)
+
+	var app gen.App
+	require.NoError(t, app.Run([]string{`sketch`, `--dev-mode`, `--dev-path`, devPath, srcDir}), `app.Run should succeed`)
+
+	_, err = os.Stat(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `generated file should be written to the current directory`)
+}
+
+func TestFilename(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Filename() string {
+	return "object_model.go"
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-jsonschema`)
+
+	for _, name := range []string{`object_model.go`, `object_model_builder.go`, `object_model_schema.json`, `sketch_gen.go`} {
+		_, err := os.Stat(filepath.Join(dstDir, name))
+		require.NoError(t, err, `%s should be generated`, name)
+	}
+	_, err := os.Stat(filepath.Join(dstDir, `object_gen.go`))
+	require.True(t, os.IsNotExist(err), `default filename should not be used`)
+}
+
+func TestOpenAPI(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Comment() string {
+	return "Object describes a server"
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true).Comment("name of the server"),
+		schema.Int64("Port"),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-openapi`)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `object_openapi_gen.json`))
+	require.NoError(t, err, `generated file should exist`)
+
+	var components map[string]interface{}
+	require.NoError(t, json.Unmarshal(generated, &components), `generated file should be valid JSON`)
+	require.Equal(t, map[string]interface{}{
+		`Object`: map[string]interface{}{
+			`description`: `Object describes a server`,
+			`type`:        `object`,
+			`properties`: map[string]interface{}{
+				`name`: map[string]interface{}{`type`: `string`, `description`: `name of the server`},
+				`port`: map[string]interface{}{`type`: `integer`, `format`: `int64`},
+			},
+			`required`: []interface{}{`name`},
+		},
+	}, components)
+}
+
+func TestPostGenerate(t *testing.T) {
+	// PostGenerate records the files that it received, and fails when
+	// the environment variable is set, so that failures can be tested
+	srcDir := newSketchModule(t, `package sketchtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lestrrat-go/sketch/schema"
+)
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+
+func PostGenerate(dir string, files []string) error {
+	if os.Getenv("SKETCHTEST_FAIL") != "" {
+		return fmt.Errorf("lint failed")
+	}
+	return os.WriteFile(filepath.Join(dir, "files.txt"), []byte(strings.Join(files, "\n")), 0644)
+}
+`)
+
+	tmplDir := t.TempDir()
+	writeFile(t, filepath.Join(tmplDir, `post-generate.tmpl`), `package {{ .Package }}
+
+// GeneratedFiles lists the files generated by sketch
+var GeneratedFiles = []string{
+{{- range .Files }}
+	{{ printf "%q" . }},
+{{- end }}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--tmpl-dir`, tmplDir)
+
+	index, err := os.ReadFile(filepath.Join(dstDir, `post_generate_gen.go`))
+	require.NoError(t, err, `post-generate.tmpl should be rendered`)
+	require.Contains(t, string(index), `"object_gen.go",`)
+	require.Contains(t, string(index), `"sketch_gen.go",`)
+
+	files, err := os.ReadFile(filepath.Join(dstDir, `files.txt`))
+	require.NoError(t, err, `PostGenerate should be called`)
+	list := strings.Split(string(files), "\n")
+	require.Contains(t, list, `object_gen.go`)
+	require.Contains(t, list, `post_generate_gen.go`, `files generated by post-generate.tmpl should be included`)
+
+	t.Setenv(`SKETCHTEST_FAIL`, `1`)
+	require.Error(t, runSketchApp(t, srcDir, dstDir, `--tmpl-dir`, tmplDir), `app.Run should fail when PostGenerate fails`)
+}
+
+func TestOverrideBlock(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	// only the block for the getters is replaced, the rest of the
+	// object is rendered by the built-in templates
+	tmplDir := t.TempDir()
+	writeFile(t, filepath.Join(tmplDir, `getters.tmpl`), `{{ define "object/getters" }}
+{{- range (fields .) }}
+// {{ .GetName }} is rendered by a user template
+func (v *{{ $.Name }}) {{ .GetName }}() string {
+  return "overridden"
+}
+{{- end }}
+{{ end }}
+`)
+
+	dstDir := runSketch(t, srcDir, `--tmpl-dir`, tmplDir)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `generated file should exist`)
+	require.Contains(t, string(generated), `// Name is rendered by a user template`)
+	require.NotContains(t, string(generated), `func (v *Object) HasName() bool`, `built-in getters should be replaced`)
+	require.Contains(t, string(generated), `func (v *Object) MarshalJSON() ([]byte, error)`, `other blocks should be rendered`)
+}
+
+func TestBuildersSameFile(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	testcases := []struct {
+		name      string
+		args      []string
+		separated bool
+	}{
+		{name: `default`, separated: true},
+		{name: `--builders-same-file`, args: []string{`--builders-same-file`}},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dstDir := filepath.Join(t.TempDir(), `out`)
+			require.NoError(t, runSketchApp(t, srcDir, dstDir, tc.args...), `app.Run should succeed`)
+
+			generated, err := os.ReadFile(filepath.Join(dstDir, `object_gen.go`))
+			require.NoError(t, err, `generated file should exist`)
+			require.Equal(t, !tc.separated, strings.Contains(string(generated), `type ObjectBuilder struct`))
+
+			generated, err = os.ReadFile(filepath.Join(dstDir, `object_builder_gen.go`))
+			if !tc.separated {
+				require.True(t, os.IsNotExist(err), `builder file should not be generated`)
+				return
+			}
+			require.NoError(t, err, `generated builder file should exist`)
+			require.Contains(t, string(generated), `type ObjectBuilder struct`)
+			require.NotContains(t, string(generated), `"encoding/json"`, `unused imports should be removed`)
+		})
+	}
+}
+
+func TestConstructorDefaults(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Int("Port").Default(8080),
+		schema.String("Host"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-constructor`)
+
+	testGenerated(t, dstDir, `constructor_test.go`, `package out
+
+import "testing"
+
+func TestConstructorDefaults(t *testing.T) {
+	v := NewObject("foo")
+	if v.GetName() != "foo" {
+		t.Errorf("unexpected name: %q", v.GetName())
+	}
+	if !v.HasPort() || v.GetPort() != 8080 {
+		t.Errorf("default values should be applied by the constructor")
+	}
+	if v.HasHost() {
+		t.Errorf("fields without default values should be left unset")
+	}
+
+	b, err := NewObjectBuilder().Name("foo").Build()
+	if err != nil {
+		t.Fatalf("Build failed: %s", err)
+	}
+	if b.GetPort() != v.GetPort() {
+		t.Errorf("the constructor and the builder should apply the same defaults")
+	}
+}
+`)
+}
+
+func TestConstructorRequiredFields(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.String("Host"),
+		schema.Int("Port").Required(true),
+	}
+}
+
+type Label struct {
+	schema.Base
+}
+
+func (Label) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Text"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-constructor`)
+
+	testGenerated(t, dstDir, `constructor_test.go`, `package out
+
+import "testing"
+
+func TestConstructorRequiredFields(t *testing.T) {
+	// required fields are passed in the order they are declared
+	v := NewObject("foo", 8080)
+	if v.GetName() != "foo" || v.GetPort() != 8080 {
+		t.Errorf("unexpected values: %q %d", v.GetName(), v.GetPort())
+	}
+	if v.HasHost() {
+		t.Errorf("optional fields should be left unset")
+	}
+
+	l := NewLabel()
+	if keys := l.Keys(); len(keys) != 0 {
+		t.Errorf("objects without required fields should be created empty (got %v)", keys)
+	}
+}
+`)
+}
+
+func TestOptions(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Int("Port"),
+		schema.Field("Tags", []string(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-options`)
+
+	testGenerated(t, dstDir, `options_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	v, err := NewObject(WithName("foo"), WithPort(8080), WithTags("a", "b"))
+	if err != nil {
+		t.Fatalf("NewObject failed: %s", err)
+	}
+	if v.GetName() != "foo" || v.GetPort() != 8080 || !reflect.DeepEqual(v.GetTags(), []string{"a", "b"}) {
+		t.Errorf("unexpected values: %q %d %v", v.GetName(), v.GetPort(), v.GetTags())
+	}
+
+	v, err = NewObject(WithName("foo"))
+	if err != nil {
+		t.Fatalf("NewObject failed: %s", err)
+	}
+	if v.HasPort() || v.HasTags() {
+		t.Errorf("fields without options should be left unset")
+	}
+
+	if _, err := NewObject(WithPort(8080)); err == nil {
+		t.Errorf("NewObject should fail when a required field is not specified")
+	}
+}
+`)
+}
+
+func TestCommaOkAccessors(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("Port"),
+		schema.String("Kind").ConstantValue("\"object\""),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--accessor-style`, `comma-ok`)
+
+	testGenerated(t, dstDir, `accessor_test.go`, `package out
+
+import "testing"
+
+func TestCommaOkAccessors(t *testing.T) {
+	var v Object
+	if port, ok := v.GetPort(); ok || port != 0 {
+		t.Errorf("unset fields should return the zero value and false (got %d, %t)", port, ok)
+	}
+
+	v.Set(PortKey, 0)
+	if port, ok := v.GetPort(); !ok || port != 0 {
+		t.Errorf("fields set to the zero value should be reported as set (got %d, %t)", port, ok)
+	}
+
+	if kind, ok := v.GetKind(); !ok || kind != "object" {
+		t.Errorf("constant fields should always be reported as set (got %q, %t)", kind, ok)
+	}
+}
+`)
+}
+
+func TestPtrAccessors(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("Port"),
+		schema.String("Host"),
+		schema.String("Kind").ConstantValue(`+"`"+`"server"`+"`"+`),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-ptr-accessors`, `--with-interface`)
+
+	testGenerated(t, dstDir, `ptr_test.go`, `package out
+
+import "testing"
+
+func TestPtrAccessors(t *testing.T) {
+	v := NewObjectBuilder().PortPtr(nil).MustBuild()
+	if v.HasPort() || v.PortPtr() != nil {
+		t.Errorf("a nil pointer should leave the field unpopulated")
+	}
+
+	port := 0
+	v = NewObjectBuilder().Port(8080).PortPtr(&port).HostPtr(nil).MustBuild()
+	ptr := v.PortPtr()
+	if ptr == nil || *ptr != 0 {
+		t.Fatalf("PortPtr should return the zero value that was set")
+	}
+	*ptr = 1
+	if v.GetPort() != 0 {
+		t.Errorf("modifying the returned pointer should not modify the object")
+	}
+
+	v = NewObjectBuilder().Port(8080).PortPtr(nil).MustBuild()
+	if v.HasPort() {
+		t.Errorf("a nil pointer should discard the previously specified value")
+	}
+}
+`)
+}
+
+func TestStructTags(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Tag("validate", "required").Tag("db", "name"),
+		schema.Int("Port").OmitEmpty(false),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--struct-tags`)
+
+	testGenerated(t, dstDir, `tags_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStructTags(t *testing.T) {
+	typ := reflect.TypeOf(Object{})
+	expected := map[string]reflect.StructTag{
+		"name": `+"`"+`json:"name,omitempty" db:"name" validate:"required"`+"`"+`,
+		"port": `+"`"+`json:"port"`+"`"+`,
+	}
+	for name, tag := range expected {
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			t.Fatalf("field %s should exist", name)
+		}
+		if field.Tag != tag {
+			t.Errorf("unexpected tag for %s: %s", name, field.Tag)
+		}
+	}
+}
+`, `-structtag=false`) // the tags are placed on unexported fields
+}
+
+func TestTOMLHelpers(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	testcases := []struct {
+		name     string
+		args     []string
+		withTOML bool
+	}{
+		{name: `default`},
+		{name: `--with-toml`, args: []string{`--with-toml`}, withTOML: true},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dstDir := filepath.Join(t.TempDir(), `out`)
+			require.NoError(t, runSketchApp(t, srcDir, dstDir, tc.args...), `app.Run should succeed`)
+
+			generated, err := os.ReadFile(filepath.Join(dstDir, `sketch_gen.go`))
+			require.NoError(t, err, `generated file should exist`)
+			require.Equal(t, tc.withTOML, strings.Contains(string(generated), `func tomlValue(`), `tomlValue should only be generated when it is used`)
+		})
+	}
+}
+
+func TestEmitGoGenerate(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--emit-go-generate`, `--with-yaml`)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `sketch_gen.go`))
+	require.NoError(t, err, `generated file should exist`)
+	require.Contains(t, string(generated), "\n//go:generate go run github.com/lestrrat-go/sketch/cmd/sketch --emit-go-generate --with-yaml ..\n")
+}
+
+func TestExcludeSymbol(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) GenerateSymbol(s string) bool {
+	return s != "object.method.Remove"
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir,
+		`--accessor-style`, `comma-ok`,
+		`--exclude-symbol`, `^builder\.method\.Name$`,
+	)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `generated file should exist`)
+	src := string(generated)
+
+	generated, err = os.ReadFile(filepath.Join(dstDir, `object_builder_gen.go`))
+	require.NoError(t, err, `generated builder file should exist`)
+	builderSrc := string(generated)
+
+	// patterns from the command line are honored even though the schema
+	// provides its own GenerateSymbol
+	require.False(t, strings.Contains(builderSrc, `func (b *ObjectBuilder) Name(`), `builder method for Name should be excluded`)
+	require.True(t, strings.Contains(builderSrc, `func (b *ObjectBuilder) Build(`), `Build should be generated`)
+	require.False(t, strings.Contains(src, `func (v *Object) Remove(`), `Remove should be excluded by the schema`)
+	require.True(t, strings.Contains(src, `func (v *Object) GetName(`), `GetName should be generated`)
+	require.True(t, strings.Contains(src, `func (v *Object) Name(`), `Name should be generated`)
+}
+
+func TestMarshalJSONOrder(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Zeta"),
+		schema.String("Unset"),
+		schema.Int("Alpha").OmitEmpty(false),
+		schema.Field("Mid", []string(nil)),
+		schema.Field("Data", []byte(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `order_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSONOrder(t *testing.T) {
+	var v Object
+	v.Set("b", 2)
+	v.Set(DataKey, []byte("hi"))
+	v.Set(MidKey, []string{"a"})
+	v.Set("a", 1)
+	v.Set(ZetaKey, "z")
+
+	buf, err := json.Marshal(&v)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+
+	// fields are written in the order they are declared, followed by
+	// the extra fields in alphabetical order. Unset fields are omitted,
+	// unless they are declared with OmitEmpty(false)
+	const expected = "{\"zeta\":\"z\",\"alpha\":0,\"mid\":[\"a\"],\"data\":\"aGk=\",\"a\":1,\"b\":2}"
+	if string(buf) != expected {
+		t.Errorf("expected %s, got %s", expected, buf)
+	}
+}
+`)
+}
+
+func TestStrictJSON(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").JSON("title"),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+
+type Loose struct {
+	schema.Base
+}
+
+func (Loose) StrictJSON() bool {
+	return false
+}
+
+func (Loose) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Text"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-strict-json`)
+
+	testGenerated(t, dstDir, `strict_test.go`, `package out
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStrictJSON(t *testing.T) {
+	var v Object
+	if err := json.Unmarshal([]byte("{\"title\":\"foo\"}"), &v); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+	if v.GetName() != "foo" {
+		t.Errorf("names specified via FieldSpec.JSON should be accepted (got %q)", v.GetName())
+	}
+
+	for _, src := range []string{"{\"title\":\"foo\",\"foo\":1}", "{\"name\":\"foo\"}", "{\"memo\":\"foo\"}"} {
+		var v Object
+		err := json.Unmarshal([]byte(src), &v)
+		if err == nil || !strings.Contains(err.Error(), "unknown field") || !strings.Contains(err.Error(), "for object Object") {
+			t.Errorf("unknown keys in %s should be rejected (got %v)", src, err)
+		}
+	}
+
+	var l Loose
+	if err := json.Unmarshal([]byte("{\"text\":\"foo\",\"foo\":1}"), &l); err != nil {
+		t.Fatalf("schemas can opt out of strict decoding: %s", err)
+	}
+	if !l.Has("foo") {
+		t.Errorf("unknown keys should be stored as extra fields")
+	}
+}
+`)
+}
+
+func TestYAML(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").YAML("title"),
+		schema.Int("Port"),
+		schema.Duration("Timeout"),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-yaml`)
+
+	// the generated code imports github.com/lestrrat-go/sketch/duration
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+	writeFile(t, filepath.Join(srcDir, `go.mod`), "module example.com/sketchtest\n\ngo 1.18\n\nrequire github.com/lestrrat-go/sketch v0.0.0\n\nreplace github.com/lestrrat-go/sketch => "+devPath+"\n")
+
+	testGenerated(t, dstDir, `yaml_test.go`, `package out
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAML(t *testing.T) {
+	const src = "extra: bar\nport: 8080\ntimeout: 1h30m0s\ntitle: foo\n"
+
+	var v Object
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %s", err)
+	}
+	if v.GetName() != "foo" || v.GetPort() != 8080 || v.GetTimeout() != 90*time.Minute {
+		t.Errorf("unexpected values: %q %d %s", v.GetName(), v.GetPort(), v.GetTimeout())
+	}
+	if !v.Has("extra") {
+		t.Errorf("unknown keys should be stored as extra fields")
+	}
+
+	buf, err := yaml.Marshal(&v)
+	if err != nil {
+		t.Fatalf("yaml.Marshal failed: %s", err)
+	}
+	if string(buf) != src {
+		t.Errorf("round trip failed: expected %q, got %q", src, buf)
+	}
+
+	if err := yaml.Unmarshal([]byte("timeout: forever\n"), &v); err == nil {
+		t.Errorf("values that are not accepted should be rejected")
+	}
+}
+`)
+}
+
+func TestMsgpack(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Field("Data", []byte(nil)),
+		schema.Duration("Timeout"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-msgpack`)
+
+	// the generated code imports github.com/lestrrat-go/sketch/duration
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+	writeFile(t, filepath.Join(srcDir, `go.mod`), "module example.com/sketchtest\n\ngo 1.18\n\nrequire github.com/lestrrat-go/sketch v0.0.0\n\nreplace github.com/lestrrat-go/sketch => "+devPath+"\n")
+
+	testGenerated(t, dstDir, `msgpack_test.go`, `package out
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpack(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").Data(1, 2).Timeout(time.Second).MustBuild()
+	v.Set("b", 2)
+	v.Set("a", 1)
+
+	buf, err := msgpack.Marshal(v)
+	if err != nil {
+		t.Fatalf("msgpack.Marshal failed: %s", err)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := msgpack.Marshal(v)
+		if err != nil {
+			t.Fatalf("msgpack.Marshal failed: %s", err)
+		}
+		if !bytes.Equal(buf, again) {
+			t.Fatalf("encoding should be deterministic")
+		}
+	}
+
+	var decoded Object
+	if err := msgpack.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("msgpack.Unmarshal failed: %s", err)
+	}
+	if decoded.GetName() != "foo" || !bytes.Equal(decoded.GetData(), []byte{1, 2}) || decoded.GetTimeout() != time.Second {
+		t.Errorf("unexpected values: %q %v %s", decoded.GetName(), decoded.GetData(), decoded.GetTimeout())
+	}
+	if !reflect.DeepEqual(decoded.Keys(), v.Keys()) {
+		t.Errorf("expected keys %v, got %v", v.Keys(), decoded.Keys())
+	}
+
+	var raw map[string]interface{}
+	if err := msgpack.Unmarshal(buf, &raw); err != nil {
+		t.Fatalf("msgpack.Unmarshal failed: %s", err)
+	}
+	if _, ok := raw[DataKey].([]byte); !ok {
+		t.Errorf("[]byte fields should be encoded as binary (got %T)", raw[DataKey])
+	}
+}
+`)
+}
+
+func TestJSONEncodingRoundTrip(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Field("SliceBase64", schema.Type([]byte(nil)).JSONEncoding(schema.JSONEncodingBase64)),
+		schema.Field("SliceHex", schema.Type([]byte(nil)).JSONEncoding(schema.JSONEncodingHex)),
+		schema.Field("SliceArray", schema.Type([]byte(nil)).JSONEncoding(schema.JSONEncodingArray)),
+		schema.Field("ArrayBase64", [4]byte{}),
+		schema.Field("ArrayHex", schema.Type([4]byte{}).JSONEncoding(schema.JSONEncodingHex)),
+		schema.Field("ArrayArray", schema.Type([4]byte{}).JSONEncoding(schema.JSONEncodingArray)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `roundtrip_test.go`, `package out
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	const src = `+"`"+`{"sliceBase64":"AQL/","sliceHex":"0102ff","sliceArray":[1,2,255],"arrayBase64":"AQIDBA==","arrayHex":"01020304","arrayArray":[1,2,3,4]}`+"`"+`
+
+	var v Object
+	if err := json.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+
+	for _, b := range [][]byte{v.GetSliceBase64(), v.GetSliceHex(), v.GetSliceArray()} {
+		if !bytes.Equal(b, []byte{1, 2, 255}) {
+			t.Errorf("unexpected slice value %v", b)
+		}
+	}
+	for _, a := range [][4]byte{v.GetArrayBase64(), v.GetArrayHex(), v.GetArrayArray()} {
+		if a != [4]byte{1, 2, 3, 4} {
+			t.Errorf("unexpected array value %v", a)
+		}
+	}
+
+	buf, err := json.Marshal(&v)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+	if string(buf) != src {
+		t.Errorf("round trip failed: expected %s, got %s", src, buf)
+	}
+
+	if err := json.Unmarshal([]byte(`+"`"+`{"arrayHex":"010203"}`+"`"+`), &v); err == nil {
+		t.Errorf("values with mismatched lengths should be rejected")
+	}
+}
+`)
+}
+
+func TestEmbed(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Metadata) GetKeyName(name string) string {
+	return "Meta" + name
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Owner"),
+		schema.String("Kind").ConstantValue(`+"`"+`"document"`+"`"+`),
+	}
+}
+
+type Document struct {
+	schema.Base
+}
+
+func (Document) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Document) Fields() []*schema.FieldSpec {
+	return append([]*schema.FieldSpec{
+		schema.String("Title"),
+	}, schema.Embed(Metadata{})...)
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `embed_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmbed(t *testing.T) {
+	var v Document
+	if err := json.Unmarshal([]byte(`+"`"+`{"title":"foo","owner":"bar"}`+"`"+`), &v); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+	if !v.Has(MetaOwner) {
+		t.Errorf("promoted fields should use the key names of the embedded object")
+	}
+	if v.GetKind() != MetadataKindValue {
+		t.Errorf("unexpected value: %q", v.GetKind())
+	}
+}
+`)
+}
+
+func TestNestedBuilder(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Owner").Required(true),
+	}
+}
+
+type Document struct {
+	schema.Base
+}
+
+func (Document) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Document) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Title"),
+		schema.Field("Metadata", schema.TypeName("*Metadata")),
+		schema.Field("Parent", schema.TypeName("*Unknown")),
+	}
+}
+
+type Unknown struct {
+	Name string
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `document_builder_gen.go`))
+	require.NoError(t, err, `generated file should exist`)
+	require.NotContains(t, string(generated), `BuildParent`, `types that are not generated objects should not have nested builders`)
+
+	testGenerated(t, dstDir, `nested_test.go`, `package out
+
+import "testing"
+
+type Unknown struct {
+	Name string
+}
+
+func TestNestedBuilder(t *testing.T) {
+	doc, err := NewDocumentBuilder().
+		Title("foo").
+		BuildMetadata(func(b *MetadataBuilder) {
+			b.Owner("alice")
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %s", err)
+	}
+	if doc.GetMetadata().GetOwner() != "alice" {
+		t.Errorf("unexpected owner: %q", doc.GetMetadata().GetOwner())
+	}
+
+	_, err = NewDocumentBuilder().
+		BuildMetadata(func(*MetadataBuilder) {}).
+		Build()
+	if err == nil {
+		t.Errorf("errors in building nested objects should be reported")
+	}
+}
+`)
+}
+
+func TestClone(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Int("Port"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Meta", map[string]int(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-clone`)
+
+	testGenerated(t, dstDir, `clone_test.go`, `package out
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").Tags("a").Meta(map[string]int{"a": 1}).MustBuild()
+	v.Set("extra", "bar")
+
+	c := v.Clone()
+	if c == v {
+		t.Fatalf("Clone should return a new object")
+	}
+	if c.GetName() != "foo" || c.HasPort() || !c.Has("extra") {
+		t.Errorf("set fields should be copied, and unset fields left unset: %v", c.Keys())
+	}
+
+	v.GetTags()[0] = "b"
+	v.GetMeta()["a"] = 2
+	if c.GetTags()[0] != "a" || c.GetMeta()["a"] != 1 {
+		t.Errorf("slices and maps should not be shared with the original")
+	}
+
+	c.Set(NameKey, "bar")
+	if v.GetName() != "foo" {
+		t.Errorf("changes to the clone should not affect the original")
+	}
+}
+`)
+}
+
+func TestMerge(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Int("Port"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Meta", map[string]int(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-merge`)
+
+	testGenerated(t, dstDir, `merge_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").Port(1).MustBuild()
+	other := NewObjectBuilder().Port(2).Tags("a").MustBuild()
+	other.Set("extra", "bar")
+
+	v.Merge(other)
+	if v.GetName() != "foo" {
+		t.Errorf("fields that are unset in other should be retained (got %q)", v.GetName())
+	}
+	if v.GetPort() != 2 || !reflect.DeepEqual(v.GetTags(), []string{"a"}) || !v.Has("extra") {
+		t.Errorf("fields that are set in other should be overlaid: %d %v %v", v.GetPort(), v.GetTags(), v.Keys())
+	}
+
+	other.GetTags()[0] = "b"
+	if v.GetTags()[0] != "a" {
+		t.Errorf("slices should not be shared with other")
+	}
+
+	// neither should block or change anything
+	v.Merge(v)
+	v.Merge(nil)
+	if v.GetPort() != 2 {
+		t.Errorf("unexpected port: %d", v.GetPort())
+	}
+}
+`)
+}
+
+func TestBuilderFrom(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Int("Port"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Meta", map[string]int(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `from_test.go`, `package out
+
+import "testing"
+
+func TestBuilderFrom(t *testing.T) {
+	src := NewObjectBuilder().Name("foo").Port(1).MustBuild()
+
+	v := NewObjectBuilder().From(src).Name("bar").MustBuild()
+	if v.GetName() != "bar" || v.GetPort() != 1 {
+		t.Errorf("unexpected values: %q %d", v.GetName(), v.GetPort())
+	}
+	if v.HasTags() || v.HasMeta() {
+		t.Errorf("fields that are unset in the source should be left unset")
+	}
+	if src.GetName() != "foo" {
+		t.Errorf("the source should not be modified (got %q)", src.GetName())
+	}
+}
+`)
+}
+
+func TestCloneNested(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Owner"),
+	}
+}
+
+type Document struct {
+	schema.Base
+}
+
+func (Document) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Title"),
+		schema.Field("Metadata", schema.TypeName("*Metadata")),
+		schema.Field("Parent", schema.TypeName("*Unknown")),
+	}
+}
+
+type Unknown struct {
+	Name string
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-clone`, `--with-merge`)
+
+	testGenerated(t, dstDir, `clone_test.go`, `package out
+
+import "testing"
+
+type Unknown struct {
+	Name string
+}
+
+func TestCloneNested(t *testing.T) {
+	doc, err := NewDocumentBuilder().
+		Title("foo").
+		BuildMetadata(func(b *MetadataBuilder) {
+			b.Owner("alice")
+		}).
+		Parent(&Unknown{Name: "parent"}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %s", err)
+	}
+
+	copies := map[string]*Document{
+		"Clone": doc.Clone(),
+		"Merge": func() *Document {
+			var v Document
+			v.Merge(doc)
+			return &v
+		}(),
+		"From": NewDocumentBuilder().From(doc).MustBuild(),
+	}
+	for name, c := range copies {
+		if c.GetMetadata() == doc.GetMetadata() {
+			t.Errorf("%s: nested objects should be cloned", name)
+			continue
+		}
+		c.GetMetadata().Set(OwnerKey, "bob")
+		if doc.GetMetadata().GetOwner() != "alice" {
+			t.Errorf("%s: modifying the copy should not affect the original", name)
+		}
+		if c.GetParent() != doc.GetParent() {
+			t.Errorf("%s: values of pointer types should be shared", name)
+		}
+	}
+}
+`)
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "encoding/xml", "fmt", "sort", "sync"}
+}
+
+func (Object) XMLNamespace() string {
+	return "urn:example"
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("ID").XMLAttr(true),
+		schema.String("Name").XML("title"),
+		schema.Field("Tags", []string(nil)).XML("tag"),
+		schema.Field("Owner", schema.TypeName("*Person")),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+
+type Person struct {
+	schema.Base
+}
+
+func (Person) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Email"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir,
+		`--with-xml`,
+	)
+
+	testGenerated(t, dstDir, `xml_test.go`, `package out
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestXML(t *testing.T) {
+	const src = `+"`"+`<Object xmlns="urn:example" id="1"><title>foo</title><tag>a</tag><tag>b</tag><owner><email>bar</email></owner></Object>`+"`"+`
+
+	var v Object
+	if err := xml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("xml.Unmarshal failed: %s", err)
+	}
+	if v.GetID() != 1 || v.GetName() != "foo" || !reflect.DeepEqual(v.GetTags(), []string{"a", "b"}) {
+		t.Errorf("unexpected values: %d %q %v", v.GetID(), v.GetName(), v.GetTags())
+	}
+	if v.GetOwner().GetEmail() != "bar" {
+		t.Errorf("unexpected nested value: %q", v.GetOwner().GetEmail())
+	}
+
+	buf, err := xml.Marshal(&v)
+	if err != nil {
+		t.Fatalf("xml.Marshal failed: %s", err)
+	}
+	if string(buf) != src {
+		t.Errorf("round trip failed: expected %s, got %s", src, buf)
+	}
+}
+`)
+}
+
+func TestFormRoundTrip(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "net/url", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Int("Age"),
+		schema.Bool("Subscribe"),
+		schema.Float64("Ratio"),
+		schema.Field("Tags", []string(nil)).Form("tag"),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir,
+		`--with-form`,
+	)
+
+	testGenerated(t, dstDir, `form_test.go`, `package out
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestForm(t *testing.T) {
+	values, err := url.ParseQuery("name=foo&age=30&subscribe=on&ratio=0.5&tag=a&tag=b&unknown=x")
+	if err != nil {
+		t.Fatalf("url.ParseQuery failed: %s", err)
+	}
+
+	var v Object
+	if err := v.DecodeForm(values); err != nil {
+		t.Fatalf("DecodeForm failed: %s", err)
+	}
+	if v.GetName() != "foo" || v.GetAge() != 30 || !v.GetSubscribe() || v.GetRatio() != 0.5 || !reflect.DeepEqual(v.GetTags(), []string{"a", "b"}) {
+		t.Errorf("unexpected values: %q %d %t %f %v", v.GetName(), v.GetAge(), v.GetSubscribe(), v.GetRatio(), v.GetTags())
+	}
+
+	const expected = "age=30&name=foo&ratio=0.5&subscribe=true&tag=a&tag=b"
+	if got := v.EncodeForm().Encode(); got != expected {
+		t.Errorf("round trip failed: expected %s, got %s", expected, got)
+	}
+
+	if err := v.DecodeForm(url.Values{"name": {"foo"}, "age": {"x"}}); err == nil {
+		t.Errorf("DecodeForm should fail for invalid numbers")
+	}
+	if err := v.DecodeForm(url.Values{"age": {"1"}}); err == nil {
+		t.Errorf("DecodeForm should fail when required fields are missing")
+	}
+}
+`)
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, format := range []string{`json`, `gob`} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			imports := `"bytes", "encoding/json", "fmt", "sort", "sync"`
+			if format == `gob` {
+				imports += `, "encoding/gob"`
+			}
+
+			srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{`+imports+`}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Int("Age"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Scores", map[string]int(nil)),
+		schema.String("Password").WriteOnly(true),
+		schema.String("Nick").OmitEmpty(true),
+		schema.String("Note").OmitEmpty(false),
+		schema.Field("Metadata", schema.TypeName("*Metadata")),
+	}
+}
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Owner"),
+		schema.String("Token").WriteOnly(true),
+	}
+}
+`)
+
+			dstDir := runSketch(t, srcDir,
+				`--with-binary`,
+				`--binary-format`, format,
+				`--with-equal`,
+				`--with-diff`,
+			)
+
+			testGenerated(t, dstDir, `binary_test.go`, `package out
+
+import (
+	"bytes"
+	"encoding"
+	"reflect"
+	"testing"
+)
+
+var _ encoding.BinaryMarshaler = (*Object)(nil)
+var _ encoding.BinaryUnmarshaler = (*Object)(nil)
+
+func TestBinary(t *testing.T) {
+	var v Object
+	v.Set(NameKey, "foo")
+	v.Set(TagsKey, []string{})
+	v.Set(ScoresKey, map[string]int{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5})
+	v.Set(PasswordKey, "secret")
+	v.Set(NickKey, "")
+	v.Set("extra", "bar")
+
+	var meta Metadata
+	meta.Set(OwnerKey, "alice")
+	meta.Set(TokenKey, "token")
+	v.Set(MetadataKey, &meta)
+
+	buf, err := v.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %s", err)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := v.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: %s", err)
+		}
+		if !bytes.Equal(buf, again) {
+			t.Fatalf("MarshalBinary is not deterministic")
+		}
+	}
+
+	var decoded Object
+	if err := decoded.UnmarshalBinary(buf); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %s", err)
+	}
+	if decoded.GetName() != "foo" || decoded.HasAge() || !decoded.HasTags() || len(decoded.GetScores()) != 5 {
+		t.Errorf("unexpected values: %q %v %v %v", decoded.GetName(), decoded.HasAge(), decoded.HasTags(), decoded.GetScores())
+	}
+	if !reflect.DeepEqual(decoded.Keys(), v.Keys()) {
+		t.Errorf("expected keys %v, got %v", v.Keys(), decoded.Keys())
+	}
+	if !decoded.Equal(&v) {
+		t.Errorf("decoded object should be equal to the original (diff: %v)", decoded.Diff(&v))
+	}
+	if decoded.HasNote() {
+		t.Errorf("Note should not be set")
+	}
+	if got := decoded.GetPassword(); got != "secret" {
+		t.Errorf("expected write-only Password to survive, got %q", got)
+	}
+	if got := decoded.GetMetadata().GetToken(); got != "token" {
+		t.Errorf("expected nested write-only Token to survive, got %q", got)
+	}
+
+	var missing Object
+	buf, err = missing.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %s", err)
+	}
+	if err := decoded.UnmarshalBinary(buf); err == nil {
+		t.Errorf("UnmarshalBinary should fail when a required field is missing")
+	}
+}
+`)
+		})
+	}
+}
+
+func TestGob(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/gob", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Int("Age"),
+		schema.Int("Count"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Scores", map[string]int(nil)),
+		schema.Field("Metadata", schema.TypeName("*Metadata")),
+	}
+}
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Owner"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir,
+		`--with-gob`,
+		`--with-equal`,
+	)
+
+	testGenerated(t, dstDir, `gob_test.go`, `package out
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+var _ gob.GobEncoder = (*Object)(nil)
+var _ gob.GobDecoder = (*Object)(nil)
+
+type envelope struct {
+	Object  *Object
+	Objects []*Object
+}
+
+func TestGob(t *testing.T) {
+	var v Object
+	v.Set(NameKey, "foo")
+	v.Set(CountKey, 0)
+	v.Set(TagsKey, []string{"a", "b"})
+	v.Set(ScoresKey, map[string]int{"a": 1, "b": 2})
+	v.Set("extra", "bar")
+
+	var meta Metadata
+	meta.Set(OwnerKey, "alice")
+	v.Set(MetadataKey, &meta)
+
+	var other Object
+	other.Set(NameKey, "bar")
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(envelope{Object: &v, Objects: []*Object{&other}}); err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+
+	var decoded envelope
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+
+	got := decoded.Object
+	if got == nil {
+		t.Fatalf("object was not decoded")
+	}
+	if got.GetName() != "foo" || !reflect.DeepEqual(got.GetTags(), []string{"a", "b"}) || got.GetScores()["b"] != 2 {
+		t.Errorf("unexpected values: %q %v %v", got.GetName(), got.GetTags(), got.GetScores())
+	}
+	if !got.HasCount() || got.GetCount() != 0 {
+		t.Errorf("Count should be set to 0")
+	}
+	if got.HasAge() {
+		t.Errorf("Age should not be set")
+	}
+	if !reflect.DeepEqual(got.Keys(), v.Keys()) {
+		t.Errorf("expected keys %v, got %v", v.Keys(), got.Keys())
+	}
+	if got.GetMetadata().GetOwner() != "alice" {
+		t.Errorf("nested objects should be decoded: %v", got.GetMetadata())
+	}
+	if !got.Equal(&v) {
+		t.Errorf("decoded object should be equal to the original")
+	}
+
+	if len(decoded.Objects) != 1 || decoded.Objects[0].GetName() != "bar" || decoded.Objects[0].HasAge() || decoded.Objects[0].HasTags() {
+		t.Errorf("unexpected objects: %v", decoded.Objects)
+	}
+
+	var missing Object
+	buf.Reset()
+	if err := gob.NewEncoder(&buf).Encode(&missing); err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+	if err := gob.NewDecoder(&buf).Decode(decoded.Objects[0]); err == nil {
+		t.Errorf("Decode should fail when a required field is missing")
+	}
+}
+`)
+}
+
+func TestStringer(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.String("Password").Secret(true),
+		schema.Field("Tokens", []string(nil)).Secret(true),
+		schema.Int("Port"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-stringer`)
+
+	testGenerated(t, dstDir, `stringer_test.go`, `package out
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStringer(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").Password("hunter2").Tokens("secret1", "secret2").MustBuild()
+
+	var s fmt.Stringer = v
+	const expected = "Object{name=foo password=[REDACTED] tokens=[REDACTED len=2] port=<unset>}"
+	if s.String() != expected {
+		t.Errorf("expected %s, got %s", expected, s.String())
+	}
+	if out := fmt.Sprint(v); strings.Contains(out, "hunter2") || strings.Contains(out, "secret1") {
+		t.Errorf("secret values should not be printed: %s", out)
+	}
+}
+`)
+}
+
+func TestAsMap(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").JSON("title"),
+		schema.Int("Port"),
+		schema.Field("Data", []byte(nil)),
+		schema.Duration("Timeout"),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-asmap`)
+
+	// the generated code imports github.com/lestrrat-go/sketch/duration
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+	writeFile(t, filepath.Join(srcDir, `go.mod`), "module example.com/sketchtest\n\ngo 1.18\n\nrequire github.com/lestrrat-go/sketch v0.0.0\n\nreplace github.com/lestrrat-go/sketch => "+devPath+"\n")
+
+	testGenerated(t, dstDir, `asmap_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAsMap(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").Data(1, 2).Timeout(time.Second).MustBuild()
+
+	// only set fields are included, with their apparent types
+	expected := map[string]interface{}{
+		"title":   "foo",
+		"data":    []byte{1, 2},
+		"timeout": time.Second,
+	}
+	if m := v.AsMap(); !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %#v, got %#v", expected, m)
+	}
+}
+`)
+}
+
+func TestLogMarshal(t *testing.T) {
+	// the generated code is only inspected, so that the test does not
+	// depend on github.com/rs/zerolog
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Scores", map[string]int(nil)),
+		schema.String("Password").Secret(true),
+		schema.String("Kind").ConstantValue(`+"`"+`"object"`+"`"+`),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-logmarshal`)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `generated file should exist`)
+	for _, expected := range []string{
+		`func (v *Object) MarshalZerologObject(e *zerolog.Event) {`,
+		`e.Str(NameKey, *val)`,
+		`e.Strs(TagsKey, val)`,
+		`e.Interface(ScoresKey, *val)`,
+		"e.Str(PasswordKey, `[REDACTED]`)",
+		`e.Str(KindKey, ObjectKindValue)`,
+	} {
+		require.Contains(t, string(generated), expected)
+	}
+}
+
+func TestEqualConcurrent(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "reflect", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-equal`, `--with-diff`, `--with-merge`)
+	testGenerated(t, dstDir, `equal_test.go`, `package out
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEqualConcurrent(t *testing.T) {
+	var a, b Object
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		// writers waiting on the locks of both objects keep new readers
+		// out, which is what used to deadlock a.Equal(b) and b.Equal(a),
+		// as well as a.Diff(b) and b.Diff(a), and a.Merge(b) and b.Merge(a)
+		for _, v := range []*Object{&a, &b} {
+			v := v
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000000; j++ {
+					v.Equal(&a)
+					v.Equal(&b)
+					v.Diff(&a)
+					v.Diff(&b)
+					v.Merge(&a)
+					v.Merge(&b)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000000; j++ {
+					_ = v.Set("name", "foo")
+				}
+			}()
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("comparing objects from both sides should not deadlock")
+	}
+
+	if !a.Equal(&b) || !a.Equal(&a) {
+		t.Errorf("objects with the same values should be equal")
+	}
+}
+`)
+}
+
+func TestEqual(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Int("Port"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Meta", map[string]int(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-equal`)
+
+	testGenerated(t, dstDir, `equal_test.go`, `package out
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	a := NewObjectBuilder().Name("foo").Tags("a").Meta(map[string]int{"a": 1}).MustBuild()
+	b := NewObjectBuilder().Name("foo").Tags("a").Meta(map[string]int{"a": 1}).MustBuild()
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Errorf("objects with the same values should be equal")
+	}
+
+	b.Set(PortKey, 0)
+	if a.Equal(b) {
+		t.Errorf("set and unset fields should not be equal, even for zero values")
+	}
+
+	b = NewObjectBuilder().Name("foo").Tags("b").Meta(map[string]int{"a": 1}).MustBuild()
+	if a.Equal(b) {
+		t.Errorf("objects with different slices should not be equal")
+	}
+
+	b = NewObjectBuilder().Name("foo").Tags("a").Meta(map[string]int{"a": 1}).MustBuild()
+	b.Set("extra", 1)
+	if a.Equal(b) {
+		t.Errorf("objects with different extra fields should not be equal")
+	}
+}
+`)
+}
+
+func TestDiff(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Int("Port"),
+		schema.Field("Tags", []string(nil)),
+		schema.Field("Meta", map[string]int(nil)),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-diff`)
+
+	testGenerated(t, dstDir, `diff_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := NewObjectBuilder().Name("foo").Port(1).MustBuild()
+	a.Set("x", 1)
+	b := NewObjectBuilder().Name("foo").Port(2).Tags("a").MustBuild()
+	b.Set("x", 2)
+	b.Set("w", 1)
+
+	// declared fields come first, followed by the extra fields in
+	// alphabetical order
+	expected := []string{PortKey, TagsKey, "w", "x"}
+	if diff := a.Diff(b); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %v, got %v", expected, diff)
+	}
+	b = NewObjectBuilder().Name("foo").Port(1).MustBuild()
+	b.Set("x", 1)
+	if diff := a.Diff(b); len(diff) != 0 {
+		t.Errorf("equal objects should have no differences (got %v)", diff)
+	}
+}
+`)
+}
+
+func TestEqualNested(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Owner"),
+	}
+}
+
+type Document struct {
+	schema.Base
+}
+
+func (Document) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Title"),
+		schema.Field("Metadata", schema.TypeName("*Metadata")),
+		schema.Field("Parent", schema.TypeName("*Unknown")),
+	}
+}
+
+type Unknown struct {
+	Name string
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-clone`, `--with-equal`, `--with-diff`)
+	testGenerated(t, dstDir, `equal_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Unknown struct {
+	Name string
+}
+
+func TestEqualNested(t *testing.T) {
+	doc, err := NewDocumentBuilder().
+		Title("foo").
+		BuildMetadata(func(b *MetadataBuilder) {
+			b.Owner("alice")
+		}).
+		Parent(&Unknown{Name: "parent"}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %s", err)
+	}
+
+	c := doc.Clone()
+	if !c.Equal(doc) || !doc.Equal(c) {
+		t.Errorf("a clone should be equal to the original")
+	}
+	if diff := c.Diff(doc); len(diff) != 0 {
+		t.Errorf("a clone should not differ from the original: %v", diff)
+	}
+
+	c.Set(ParentKey, &Unknown{Name: "parent"})
+	if !c.Equal(doc) {
+		t.Errorf("values of pointer types should be compared by the values they point to")
+	}
+
+	c.GetMetadata().Set(OwnerKey, "bob")
+	if c.Equal(doc) {
+		t.Errorf("objects with different nested objects should not be equal")
+	}
+	if diff := c.Diff(doc); !reflect.DeepEqual(diff, []string{MetadataKey}) {
+		t.Errorf("unexpected diff: %v", diff)
+	}
+}
+`)
+}
+
+func TestSQL(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Field("Payload", schema.Type([]byte(nil)).SQLType("BLOB")),
+		schema.Field("Meta", schema.Type(map[string]int(nil)).SQLType("JSON")),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-sql`)
+
+	testGenerated(t, dstDir, `sql_test.go`, `package out
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+var _ sql.Scanner = (*Object)(nil)
+var _ driver.Valuer = (*Object)(nil)
+
+func TestSQL(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").Payload(1, 2).Meta(map[string]int{"a": 1}).MustBuild()
+
+	val, err := v.Value()
+	if err != nil {
+		t.Fatalf("Value failed: %s", err)
+	}
+	var decoded Object
+	if err := decoded.Scan(val); err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if decoded.GetName() != "foo" || !bytes.Equal(decoded.GetPayload(), []byte{1, 2}) || !reflect.DeepEqual(decoded.GetMeta(), map[string]int{"a": 1}) {
+		t.Errorf("the object should survive a round trip: %q %v %v", decoded.GetName(), decoded.GetPayload(), decoded.GetMeta())
+	}
+
+	payload, err := v.PayloadSQLValue()
+	if err != nil {
+		t.Fatalf("PayloadSQLValue failed: %s", err)
+	}
+	if b, ok := payload.([]byte); !ok || !bytes.Equal(b, []byte{1, 2}) {
+		t.Errorf("[]byte fields should be stored as raw bytes (got %#v)", payload)
+	}
+
+	meta, err := v.MetaSQLValue()
+	if err != nil {
+		t.Fatalf("MetaSQLValue failed: %s", err)
+	}
+	if b, ok := meta.([]byte); !ok || string(b) != "{\"a\":1}" {
+		t.Errorf("other fields should be stored as JSON (got %#v)", meta)
+	}
+
+	var fields Object
+	if err := fields.ScanPayload(payload); err != nil {
+		t.Fatalf("ScanPayload failed: %s", err)
+	}
+	if err := fields.ScanMeta(meta); err != nil {
+		t.Fatalf("ScanMeta failed: %s", err)
+	}
+	if !bytes.Equal(fields.GetPayload(), []byte{1, 2}) || !reflect.DeepEqual(fields.GetMeta(), map[string]int{"a": 1}) {
+		t.Errorf("unexpected values: %v %v", fields.GetPayload(), fields.GetMeta())
+	}
+}
+`)
+}
+
+func TestSQLScannableField(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import (
+	"example.com/sketchtest/money"
+	"github.com/lestrrat-go/sketch/schema"
+)
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "database/sql/driver", "encoding/json", "fmt", "sort", "sync", "example.com/sketchtest/money"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Field("Price", money.Cents{}),
+	}
+}
+`)
+	writeFile(t, filepath.Join(srcDir, `money`, `money.go`), `package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+type Cents struct {
+	N int64
+}
+
+func (c *Cents) Scan(src interface{}) error {
+	n, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("expected int64, got %T", src)
+	}
+	c.N = n
+	return nil
+}
+
+func (c Cents) Value() (driver.Value, error) {
+	return c.N, nil
+}
+`)
+
+	dstDir := runSketch(t, srcDir,
+		`--with-sql`,
+	)
+
+	testGenerated(t, dstDir, `sql_test.go`, `package out
+
+import "testing"
+
+func TestScanValue(t *testing.T) {
+	var v Object
+	if err := v.ScanPrice(int64(150)); err != nil {
+		t.Fatalf("ScanPrice failed: %s", err)
+	}
+	if v.GetPrice().N != 150 {
+		t.Errorf("unexpected value: %d", v.GetPrice().N)
+	}
+
+	val, err := v.PriceSQLValue()
+	if err != nil {
+		t.Fatalf("PriceSQLValue failed: %s", err)
+	}
+	if val != int64(150) {
+		t.Errorf("unexpected value: %#v", val)
+	}
+
+	if err := v.ScanPrice("foo"); err == nil {
+		t.Errorf("ScanPrice should fail for unsupported values")
+	}
+
+	if err := v.ScanPrice(nil); err != nil {
+		t.Fatalf("ScanPrice failed: %s", err)
+	}
+	if v.HasPrice() {
+		t.Errorf("NULL should clear the field")
+	}
+	val, err = v.PriceSQLValue()
+	if err != nil || val != nil {
+		t.Errorf("unpopulated fields should return nil: %#v, %v", val, err)
+	}
+}
+
+func TestScanObject(t *testing.T) {
+	var v Object
+	if err := v.Scan(`+"`"+`{"name":"foo","extra":1}`+"`"+`); err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if v.GetName() != "foo" || !v.Has("extra") {
+		t.Errorf("unexpected values: %q %v", v.GetName(), v.Keys())
+	}
+
+	if err := v.Scan(nil); err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if keys := v.Keys(); len(keys) != 0 {
+		t.Errorf("NULL should clear the object: %v", keys)
+	}
+}
+`)
+}
+
+func TestJSONNull(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import (
+	"example.com/sketchtest/celsius"
+	"github.com/lestrrat-go/sketch/schema"
+)
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync", "example.com/sketchtest/celsius"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("X"),
+		schema.Field("Temperature", schema.Type(celsius.Celsius{}).AcceptValue(true)),
+	}
+}
+`)
+	// Celsius rejects nil, so that the test fails if null is passed to AcceptValue
+	writeFile(t, filepath.Join(srcDir, `celsius`, `celsius.go`), `package celsius
+
+import "fmt"
+
+type Celsius struct {
+	Value float64
+}
+
+func (c *Celsius) AcceptValue(v interface{}) error {
+	f, ok := v.(float64)
+	if !ok {
+		return fmt.Errorf("expected float64, got %T", v)
+	}
+	c.Value = f
+	return nil
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `null_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNull(t *testing.T) {
+	testcases := []struct {
+		src      string
+		has      bool
+		expected string
+	}{
+		{src: `+"`"+`{}`+"`"+`, expected: `+"`"+`{}`+"`"+`},
+		{src: `+"`"+`{"x":null,"temperature":null}`+"`"+`, expected: `+"`"+`{}`+"`"+`},
+		{src: `+"`"+`{"x":0}`+"`"+`, has: true, expected: `+"`"+`{"x":0}`+"`"+`},
+	}
+
+	for _, tc := range testcases {
+		var v Object
+		if err := json.Unmarshal([]byte(tc.src), &v); err != nil {
+			t.Fatalf("json.Unmarshal(%s) failed: %s", tc.src, err)
+		}
+		if v.HasX() != tc.has {
+			t.Errorf("%s: expected HasX() to be %t", tc.src, tc.has)
+		}
+		if v.HasTemperature() {
+			t.Errorf("%s: expected temperature to be unset", tc.src)
+		}
+
+		buf, err := json.Marshal(&v)
+		if err != nil {
+			t.Fatalf("json.Marshal failed: %s", err)
+		}
+		if string(buf) != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.src, tc.expected, buf)
+		}
+	}
+
+	// null clears a value assigned by an earlier key
+	var v Object
+	if err := json.Unmarshal([]byte(`+"`"+`{"x":1,"x":null}`+"`"+`), &v); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+	if v.HasX() {
+		t.Errorf("expected null to clear the earlier value")
+	}
+}
+`)
+}
+
+func TestDuration(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync", "time", "github.com/lestrrat-go/sketch/duration"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Duration("Timeout"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	// the generated code imports github.com/lestrrat-go/sketch/duration
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+	writeFile(t, filepath.Join(srcDir, `go.mod`), "module example.com/sketchtest\n\ngo 1.18\n\nrequire github.com/lestrrat-go/sketch v0.0.0\n\nreplace github.com/lestrrat-go/sketch => "+devPath+"\n")
+
+	testGenerated(t, dstDir, `duration_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	v := NewObjectBuilder().Timeout(90 * time.Minute).MustBuild()
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+	if string(buf) != `+"`"+`{"timeout":"1h30m0s"}`+"`"+` {
+		t.Errorf("unexpected JSON: %s", buf)
+	}
+
+	for _, src := range []string{`+"`"+`{"timeout":"1h30m"}`+"`"+`, `+"`"+`{"timeout":5400000000000}`+"`"+`} {
+		var decoded Object
+		if err := json.Unmarshal([]byte(src), &decoded); err != nil {
+			t.Fatalf("json.Unmarshal(%s) failed: %s", src, err)
+		}
+		if decoded.GetTimeout() != 90*time.Minute {
+			t.Errorf("unexpected value decoded from %s: %s", src, decoded.GetTimeout())
+		}
+	}
+
+	for _, src := range []string{`+"`"+`{"timeout":null}`+"`"+`, `+"`"+`{"timeout":""}`+"`"+`} {
+		var decoded Object
+		if err := json.Unmarshal([]byte(src), &decoded); err != nil {
+			t.Fatalf("json.Unmarshal(%s) failed: %s", src, err)
+		}
+		if decoded.HasTimeout() {
+			t.Errorf("%s should leave the field unset", src)
+		}
+	}
+
+	var decoded Object
+	if err := json.Unmarshal([]byte(`+"`"+`{"timeout":"forever"}`+"`"+`), &decoded); err == nil {
+		t.Errorf("invalid durations should be rejected")
+	}
+}
+`)
+}
+
+func TestUUID(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync", "github.com/google/uuid", "github.com/lestrrat-go/sketch/uuidvalue"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.UUID("ID"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	// the generated code imports github.com/lestrrat-go/sketch/uuidvalue,
+	// which is a separate module
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+	writeFile(t, filepath.Join(srcDir, `go.mod`), "module example.com/sketchtest\n\ngo 1.18\n\n"+
+		"require (\n\tgithub.com/google/uuid v1.6.0\n\tgithub.com/lestrrat-go/sketch v0.0.0\n\tgithub.com/lestrrat-go/sketch/uuidvalue v0.0.0\n)\n\n"+
+		"replace github.com/lestrrat-go/sketch => "+devPath+"\n\n"+
+		"replace github.com/lestrrat-go/sketch/uuidvalue => "+filepath.Join(devPath, `uuidvalue`)+"\n")
+
+	testGenerated(t, dstDir, `uuid_test.go`, `package out
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUUID(t *testing.T) {
+	id := uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	v := NewObjectBuilder().ID(id).MustBuild()
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+	if string(buf) != `+"`"+`{"id":"f47ac10b-58cc-4372-a567-0e02b2c3d479"}`+"`"+` {
+		t.Errorf("unexpected JSON: %s", buf)
+	}
+
+	var decoded Object
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+	if decoded.GetID() != id {
+		t.Errorf("unexpected value: %s", decoded.GetID())
+	}
+
+	if err := decoded.Set("id", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+	if decoded.GetID().String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Errorf("strings should be parsed as UUIDs")
+	}
+
+	err = json.Unmarshal([]byte(`+"`"+`{"id":"not-a-uuid"}`+"`"+`), &decoded)
+	if err == nil || !strings.Contains(err.Error(), `+"`"+`"id"`+"`"+`) {
+		t.Errorf("invalid UUIDs should be rejected with the name of the field (got %v)", err)
+	}
+}
+`)
+}
+
+func TestRawJSON(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.RawJSON("Payload"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `rawjson_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRawJSON(t *testing.T) {
+	const src = `+"`"+`{"name":"foo","payload":{"b":[1,2.50,"x"],"a":null}}`+"`"+`
+	var v Object
+	if err := json.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+	if string(v.GetPayload()) != `+"`"+`{"b":[1,2.50,"x"],"a":null}`+"`"+` {
+		t.Errorf("the payload should be captured as is (got %s)", v.GetPayload())
+	}
+
+	buf, err := json.Marshal(&v)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+	if string(buf) != src {
+		t.Errorf("the payload should be written back as is (got %s)", buf)
+	}
+
+	empty := NewObjectBuilder().Name("foo").Payload(json.RawMessage{}).MustBuild()
+	buf, err = json.Marshal(empty)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+	if string(buf) != `+"`"+`{"name":"foo"}`+"`"+` {
+		t.Errorf("empty payloads should be omitted (got %s)", buf)
+	}
+}
+`)
+}
+
+func TestValidate(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true).MinLen(2).MaxLen(8).Pattern("^[a-z]+$"),
+		schema.Int("Port").Min(1).Max(65535),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-validation`)
+
+	testGenerated(t, dstDir, `validate_test.go`, `package out
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	var v Object
+	if err := v.Validate(); err == nil || !strings.Contains(err.Error(), "required field name") {
+		t.Errorf("missing required fields should be reported (got %v)", err)
+	}
+
+	testcases := []struct {
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{key: NameKey, value: "f", expected: "field name must have length greater than or equal to 2"},
+		{key: NameKey, value: "foobarbaz", expected: "field name must have length less than or equal to 8"},
+		{key: NameKey, value: "Foo", expected: "field name must match pattern"},
+		{key: PortKey, value: 0, expected: "field port must be greater than or equal to 1"},
+		{key: PortKey, value: 65536, expected: "field port must be less than or equal to 65535"},
+	}
+	for _, tc := range testcases {
+		v := NewObjectBuilder().Name("foo").Port(8080).MustBuild()
+		if err := v.Validate(); err != nil {
+			t.Fatalf("valid values should be accepted: %s", err)
+		}
+		if err := v.Set(tc.key, tc.value); err != nil {
+			t.Fatalf("Set failed: %s", err)
+		}
+		if err := v.Validate(); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("expected an error containing %q, got %v", tc.expected, err)
+		}
+	}
+
+	if _, err := NewObjectBuilder().Name("f").Build(); err == nil {
+		t.Errorf("Build should reject values that violate the constraints")
+	}
+}
+`)
+}
+
+func TestSettersReturnError(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) SettersReturnError() bool {
+	return true
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").MinLen(2),
+		schema.Int("Port").Min(1),
+		schema.String("Nick"),
+		schema.String("Note"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `setters_test.go`, `package out
+
+import "testing"
+
+func TestSettersReturnError(t *testing.T) {
+	var v Object
+	if err := v.SetPort(0); err == nil {
+		t.Errorf("SetPort should reject values that violate the constraints")
+	}
+	if v.HasPort() {
+		t.Errorf("rejected values should not be stored")
+	}
+	if err := v.SetPort(8080); err != nil {
+		t.Fatalf("SetPort failed: %s", err)
+	}
+	if err := v.SetName("f"); err == nil {
+		t.Errorf("SetName should reject values that violate the constraints")
+	}
+	if v.GetPort() != 8080 || v.HasName() {
+		t.Errorf("unexpected values: %d %v", v.GetPort(), v.Keys())
+	}
+
+	// setters of unconstrained fields can be chained
+	if v.SetNick("foo").SetNote("bar") != &v {
+		t.Errorf("setters of unconstrained fields should return the object")
+	}
+	if v.GetNick() != "foo" || v.GetNote() != "bar" {
+		t.Errorf("unexpected values: %q %q", v.GetNick(), v.GetNote())
+	}
+}
+`)
+}
+
+func TestEnum(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Status").Enum("active", "in-progress"),
+		schema.Int("Level").Enum(1, 2).EnumTypeName("Level"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-validation`)
+
+	testGenerated(t, dstDir, `enum_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnum(t *testing.T) {
+	v := NewObjectBuilder().Status(ObjectStatusInProgress).Level(Level2).MustBuild()
+	var status ObjectStatus = v.GetStatus()
+	if status != ObjectStatusInProgress {
+		t.Errorf("the getter should return the enum type (got %q)", status)
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+	if string(buf) != `+"`"+`{"status":"in-progress","level":2}`+"`"+` {
+		t.Errorf("enums should be encoded using their underlying values (got %s)", buf)
+	}
+
+	var decoded Object
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %s", err)
+	}
+	if decoded.GetStatus() != ObjectStatusInProgress || decoded.GetLevel() != Level2 {
+		t.Errorf("enums should survive a round trip (got %q, %d)", decoded.GetStatus(), decoded.GetLevel())
+	}
+
+	if err := v.Set("status", "active"); err != nil {
+		t.Errorf("Set should accept values of the underlying type: %s", err)
+	}
+	if err := v.Set("level", Level1); err != nil {
+		t.Errorf("Set should accept values of the enum type: %s", err)
+	}
+	if err := v.Set("status", "unknown"); err == nil {
+		t.Errorf("Set should reject undeclared values")
+	}
+	if v.GetStatus() != ObjectStatusActive {
+		t.Errorf("rejected values should not be stored (got %q)", v.GetStatus())
+	}
+
+	setErr := v.Set("status", "unknown")
+	decodeErr := json.Unmarshal([]byte(`+"`"+`{"status":"unknown"}`+"`"+`), &decoded)
+	if decodeErr == nil {
+		t.Errorf("json.Unmarshal should reject undeclared values")
+	} else if decodeErr.Error() != setErr.Error() {
+		t.Errorf("json.Unmarshal should report the same error as Set (got %q, expected %q)", decodeErr, setErr)
+	}
+	if err := json.Unmarshal([]byte(`+"`"+`{"level":3}`+"`"+`), &decoded); err == nil {
+		t.Errorf("json.Unmarshal should reject undeclared integer values")
+	}
+	if _, err := NewObjectBuilder().Status("unknown").Build(); err == nil {
+		t.Errorf("Build should reject undeclared values")
+	}
+	if !ObjectStatusActive.IsValid() || ObjectStatus("unknown").IsValid() {
+		t.Errorf("IsValid should report whether the value has been declared")
+	}
+}
+`)
+}
+
+func TestEnumValueConflicts(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Status").Enum("in-progress", "in_progress"),
+	}
+}
+`)
+
+	_, output := runSketchFailure(t, srcDir)
+	require.Contains(t, output, `enum values "in-progress" and "in_progress" of field "Status" in object Object have the same constant name ObjectStatusInProgress`)
+}
+
+func TestExample(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Example("alice"),
+		schema.Int64("Port").Example(int64(8080)),
+		schema.Field("Tags", []string(nil)).Example([]string{"a", "b"}),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-jsonschema`)
+
+	generated, err := os.ReadFile(filepath.Join(dstDir, `object_schema_gen.json`))
+	require.NoError(t, err, `generated file should exist`)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(generated, &doc), `generated file should be valid JSON`)
+	require.Equal(t, map[string]interface{}{
+		`name`: map[string]interface{}{`type`: `string`, `examples`: []interface{}{`alice`}},
+		`port`: map[string]interface{}{`type`: `integer`, `examples`: []interface{}{float64(8080)}},
+		`tags`: map[string]interface{}{
+			`type`:     `array`,
+			`items`:    map[string]interface{}{`type`: `string`},
+			`examples`: []interface{}{[]interface{}{`a`, `b`}},
+		},
+	}, doc[`properties`])
+
+	srcDir = newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int64("Port").Example("8080"),
+	}
+}
+`)
+
+	_, output := runSketchFailure(t, srcDir)
+	require.Contains(t, output, `example "8080" of field "Port" in object Object is not of type int64`)
+}
+
+func TestWalk(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Int("Count"),
+		schema.Field("Tags", []string(nil)),
+		schema.String("Password").Secret(true),
+		schema.String("Kind").ConstantValue(`+"`"+`"object"`+"`"+`),
+		schema.String("Memo").IsExtension(true),
+	}
+}
+
+type Account struct {
+	schema.Base
+}
+
+func (Account) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Account) WalkSkipSecret() bool {
+	return false
+}
+
+func (Account) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("User"),
+		schema.String("Token").Secret(true),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir,
+		`--walk-skip-secret`,
+	)
+
+	testGenerated(t, dstDir, `walk_test.go`, `package out
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	var v Object
+	v.Set(TagsKey, []string{"a"})
+	v.Set(NameKey, "foo")
+	v.Set(PasswordKey, "secret")
+	v.Set("extra", "bar")
+
+	var keys []string
+	var values []interface{}
+	v.Walk(func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []string{NameKey, TagsKey, KindKey}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"foo", []string{"a"}, "object"}) {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	keys = nil
+	v.Walk(func(key string, _ interface{}) bool {
+		keys = append(keys, key)
+		return false
+	})
+	if !reflect.DeepEqual(keys, []string{NameKey}) {
+		t.Errorf("Walk should stop when fn returns false: %v", keys)
+	}
+
+	var a Account
+	a.Set(TokenKey, "secret")
+	keys = nil
+	a.Walk(func(key string, _ interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []string{TokenKey}) {
+		t.Errorf("secret fields should be visited unless skipped: %v", keys)
+	}
+}
+`)
+}
+
+func TestImports(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/lestrrat-go/sketch/schema"
+)
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Pattern("^[a-z]+$"),
+		schema.Field("CreatedAt", time.Time{}),
+		schema.Field("Links", []*url.URL(nil)),
+	}
+}
+
+type Other struct {
+	schema.Base
+}
+
+func (Other) Imports() []string {
+	return []string{"fmt", "os", "fmt", "sync"}
+}
+
+func (Other) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("Count"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir, `--with-xml`, `--with-validation`)
+
+	for _, name := range []string{`object_gen.go`, `other_gen.go`} {
+		src, err := os.ReadFile(filepath.Join(dstDir, name))
+		require.NoError(t, err, `os.ReadFile should succeed`)
+
+		f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.ImportsOnly)
+		require.NoError(t, err, `parser.ParseFile should succeed`)
+
+		var paths []string
+		for _, spec := range f.Imports {
+			paths = append(paths, spec.Path.Value)
+		}
+		require.True(t, sort.StringsAreSorted(paths), `imports in %s should be sorted: %v`, name, paths)
+		require.NotContains(t, paths, `"os"`, `unused imports in %s should be removed`, name)
+		for i := 1; i < len(paths); i++ {
+			require.NotEqual(t, paths[i-1], paths[i], `imports in %s should not be duplicated`, name)
+		}
+	}
+
+	testGenerated(t, dstDir, `imports_test.go`, `package out
+
+import (
+	"encoding/xml"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestImports(t *testing.T) {
+	var v Object
+	v.Set(CreatedAtKey, time.Unix(0, 0).UTC())
+	if err := v.Set(NameKey, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := xml.Marshal(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	v.Set(LinksKey, []*url.URL{{Scheme: "https", Host: "example.com"}})
+	if v.GetLinks()[0].Host != "example.com" {
+		t.Errorf("unexpected links: %v", v.GetLinks())
+	}
+}
+`)
+}
+
+func TestTypeImports(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+var shapeType = schema.TypeName("mypkg.Shape").
+	IsInterface(true).
+	InterfaceDecoder("mypkg.ParseShape").
+	Imports("example.com/sketchtest/mypkg")
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.Field("Shape", shapeType),
+	}
+}
+`)
+	writeFile(t, filepath.Join(srcDir, `mypkg`, `mypkg.go`), `package mypkg
+
+import "encoding/json"
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct {
+	Side float64 `+"`"+`json:"side"`+"`"+`
+}
+
+func (s Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func ParseShape(src []byte) (Shape, error) {
+	var s Square
+	if err := json.Unmarshal(src, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `typeimports_test.go`, `package out
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTypeImports(t *testing.T) {
+	var v Object
+	if err := json.Unmarshal([]byte(`+"`"+`{"name":"foo","shape":{"side":2}}`+"`"+`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if area := v.GetShape().Area(); area != 4 {
+		t.Errorf("unexpected area: %v", area)
+	}
+}
+`)
+}
+
+func TestBuildInto(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Int("Count"),
+	}
+}
+`)
+
+	dstDir := runSketch(t, srcDir)
+
+	testGenerated(t, dstDir, `buildinto_test.go`, `package out
+
+import "testing"
+
+func TestBuildInto(t *testing.T) {
+	var dst Object
+	b := NewObjectBuilder()
+	if err := b.Name("foo").Count(1).BuildInto(&dst); err != nil {
+		t.Fatalf("BuildInto failed: %s", err)
+	}
+	if dst.GetName() != "foo" || dst.GetCount() != 1 {
+		t.Errorf("the values should be stored into dst (got %q, %d)", dst.GetName(), dst.GetCount())
+	}
+
+	// the builder is reset, and the previous values of dst are discarded
+	if err := b.Name("bar").BuildInto(&dst); err != nil {
+		t.Fatalf("BuildInto failed: %s", err)
+	}
+	if dst.GetName() != "bar" || dst.HasCount() {
+		t.Errorf("the previous values of dst should be discarded (got %q, %v)", dst.GetName(), dst.HasCount())
+	}
+
+	if err := b.Count(2).BuildInto(&dst); err == nil {
+		t.Errorf("BuildInto should fail when a required field is missing")
+	}
+	if dst.GetName() != "bar" || dst.HasCount() {
+		t.Errorf("dst should be left intact on failure (got %q, %v)", dst.GetName(), dst.HasCount())
+	}
+
+	v, err := NewObjectBuilder().Name("baz").Build()
+	if err != nil {
+		t.Fatalf("Build failed: %s", err)
+	}
+	if v.GetName() != "baz" {
+		t.Errorf("Build should allocate a new object (got %q)", v.GetName())
+	}
+}
+`)
+}
+
+func TestJSONFuncRequiresBothDirections(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Field("Hosts", []string(nil)).MarshalJSONFunc("marshalHosts"),
+	}
+}
+`)
+
+	_, output := runSketchFailure(t, srcDir)
+	require.Contains(t, output, `field "Hosts" in object Object must specify both MarshalJSONFunc and UnmarshalJSONFunc`)
+}
+
+func TestReadOnlyWriteOnlyConflict(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Password").ReadOnly(true).WriteOnly(true),
+	}
+}
+`)
+
+	_, output := runSketchFailure(t, srcDir)
+	require.Contains(t, output, `field "Password" in object Object cannot be both read-only and write-only`)
+}
+
+func TestFractionalIntegerBounds(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("Port").Min(0.5).Max(65535),
+		schema.Float64("Ratio").Min(0.5).Max(1.5),
+	}
+}
+`)
+
+	_, output := runSketchFailure(t, srcDir, `--with-validation`)
+	require.Contains(t, output, `field "Port" in object Object is an integer, but its minimum value 0.5 is not`)
+	require.NotContains(t, output, `"Ratio"`, `floating point fields may have fractional bounds`)
+}
+
+func TestCBORKeyConflict(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("ID").CBORKey(1),
+		schema.String("Name").CBORKey(1),
+	}
+}
+`)
+
+	_, output := runSketchFailure(t, srcDir, `--with-cbor`)
+	require.Contains(t, output, `fields "ID" and "Name" in object Object have the same CBOR key 1`)
+}
+
+func TestErrorsAreCollected(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Good struct {
+	schema.Base
+}
+
+func (Good) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+
+type First struct {
+	schema.Base
+}
+
+func (First) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Field("Hosts", []string(nil)).MarshalJSONFunc("marshalHosts"),
+	}
+}
+
+type Second struct {
+	schema.Base
+}
+
+func (Second) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Field("Ports", []int(nil)).UnmarshalJSONFunc("unmarshalPorts"),
+	}
+}
+`)
+
+	// a file left over from a previous run
+	stale := filepath.Join(srcDir, `out`, `good_gen.go`)
+	writeFile(t, stale, "package out\n")
+
+	dstDir, output := runSketchFailure(t, srcDir)
+	require.Contains(t, output, `2 errors occurred while generating code`)
+	require.Contains(t, output, `failed to run sketch compiler: exit status 1`, `errors from the compiler should not be reported as build failures`)
+	require.Contains(t, output, `field "Hosts" in object First`, `errors from all objects should be reported`)
+	require.Contains(t, output, `field "Ports" in object Second`, `errors from all objects should be reported`)
+
+	content, err := os.ReadFile(stale)
+	require.NoError(t, err, `os.ReadFile should succeed`)
+	require.Equal(t, "package out\n", string(content), `files should not be written when any of them fails to render`)
+	_, err = os.Stat(filepath.Join(dstDir, `sketch_gen.go`))
+	require.True(t, os.IsNotExist(err), `files should not be written when any of them fails to render`)
+}
+
+func TestGenerate(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+
+	opts := gen.DefaultOptions()
+	opts.SrcDirs = []string{srcDir}
+	opts.DstDir = filepath.Join(srcDir, `out`)
+	opts.DevPath = devPath
+	opts.AccessorPrefix = ``
+
+	var app gen.App
+	require.NoError(t, app.Generate(opts), `app.Generate should succeed`)
+
+	testGenerated(t, opts.DstDir, `generate_test.go`, `package out
+
+import "testing"
+
+func TestGenerate(t *testing.T) {
+	v := NewObjectBuilder().Name("foo").MustBuild()
+	if v.Name() != "foo" {
+		t.Errorf("the options should be applied")
+	}
+}
+`)
+
+	opts.SrcDirs = append(opts.SrcDirs, t.TempDir())
+	opts.DstDir = t.TempDir()
+	require.ErrorContains(t, app.Generate(opts), `dst-dir cannot be specified when multiple schema directories are supplied`)
+}
+
+func TestListSchemas(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type User struct {
+	schema.Base
+}
+
+func (User) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name").Required(true),
+		schema.Field("Tags", []string(nil)).JSON("labels"),
+		schema.String("Status").Enum("active", "inactive"),
+	}
+}
+
+type Group struct {
+	schema.Base
+}
+
+func (Group) Name() string {
+	return "Team"
+}
+
+func (Group) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.Int("Size"),
+	}
+}
+
+type Internal struct {
+	schema.Base
+}
+
+func (Internal) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Secret"),
+	}
+}
+`)
+
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+
+	opts := gen.DefaultOptions()
+	opts.SrcDirs = []string{srcDir}
+	opts.DevPath = devPath
+	opts.ExcludeSchemas = []string{`^Internal$`}
+
+	var buf bytes.Buffer
+	var app gen.App
+	require.NoError(t, app.ListSchemas(&buf, opts), `app.ListSchemas should succeed`)
+	require.Equal(t, `OBJECT  FIELD   TYPE        REQUIRED  JSON
+User    Name    string      true      name
+User    Tags    []string    false     labels
+User    Status  UserStatus  false     status
+Team    Size    int         false     size
+`, buf.String())
+
+	entries, err := os.ReadDir(srcDir)
+	require.NoError(t, err, `os.ReadDir should succeed`)
+	for _, entry := range entries {
+		require.False(t, strings.HasSuffix(entry.Name(), `_gen.go`), `no files should be generated`)
+	}
+
+	require.ErrorContains(t, app.Run([]string{`sketch`, `--list-schemas`, `--dry-run`, srcDir}), `list-schemas cannot be used in conjunction with watch, diff, or dry-run`)
+}
+
+func TestLogOutput(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+		schema.String("Name"),
+	}
+}
+`)
+
+	devPath, err := filepath.Abs(`..`)
+	require.NoError(t, err, `filepath.Abs should succeed`)
+	run := func(app *gen.App, args ...string) error {
+		args = append([]string{`sketch`, `--dev-mode`, `--dev-path`, devPath, `--dst-dir`, filepath.Join(srcDir, `out`)}, args...)
+		return app.Run(append(args, srcDir))
+	}
+
+	var buf bytes.Buffer
+	var app gen.App
+	app.SetLogOutput(&buf)
+	app.SetLogLevel(gen.LogLevelVerbose)
+	require.Error(t, run(&app), `app.Run should fail`)
+	require.Contains(t, buf.String(), `Running "./sketch-compiler"`, `progress should be written to the log output`)
+	require.Contains(t, buf.String(), `field "Name" is declared more than once`, `errors from the compiler should be written to the log output`)
+
+	buf.Reset()
+	app = gen.App{}
+	app.SetLogOutput(&buf)
+	require.Error(t, run(&app), `app.Run should fail`)
+	require.NotContains(t, buf.String(), `Running "./sketch-compiler"`, `progress should only be written in verbose mode`)
+	require.Contains(t, buf.String(), `field "Name" is declared more than once`, `errors should be written by default`)
+
+	buf.Reset()
+	app = gen.App{}
+	app.SetLogOutput(&buf)
+	require.Error(t, run(&app, `--quiet`), `app.Run should fail`)
+	require.Empty(t, buf.String(), `--quiet should suppress all messages`)
+
+	require.ErrorContains(t, run(&app, `--quiet`, `--verbose`), `--verbose and --quiet cannot be specified at the same time`)
+}
+
+func TestModMode(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Imports() []string {
+	return []string{"bytes", "encoding/json", "fmt", "sort", "sync"}
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	}
+}
+`)
+
+	// the modules required by the compiler are already in the module
+	// cache, as they are required by sketch itself
+	dstDir := runSketch(t, srcDir, `--goproxy=off`, `--mod-mode=vendor`)
+
+	testGenerated(t, dstDir, `modmode_test.go`, `package out
+
+import "testing"
+
+func TestModMode(t *testing.T) {
+	if NewObjectBuilder().Name("foo").MustBuild().GetName() != "foo" {
+		t.Errorf("the compiler built from vendored modules should generate code")
+	}
+}
+`)
+
+	err := runSketchApp(t, srcDir, dstDir, `--mod-mode=strict`)
+	require.ErrorContains(t, err, `invalid module mode "strict"`)
+}
+
+func TestCacheDir(t *testing.T) {
+	schemaSrc := func(field string) string {
+		return `package sketchtest
+
+import (
+	"example.com/sketchtest/names"
+	"github.com/lestrrat-go/sketch/schema"
+)
+
+type Object struct {
+	schema.Base
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("` + field + `"),
+		schema.String(names.Extra),
+	}
+}
+`
+	}
+	namesSrc := func(field string) string {
+		return `package names
+
+const Extra = "` + field + `"
+`
+	}
+	srcDir := newSketchModule(t, schemaSrc(`Name`))
+	writeFile(t, filepath.Join(srcDir, `names`, `names.go`), namesSrc(`Subtitle`))
+	dstDir := filepath.Join(srcDir, `out`)
+	cacheDir := t.TempDir()
+
+	run := func(args ...string) {
+		t.Helper()
+		runSketch(t, srcDir, append([]string{`--cache-dir`, cacheDir}, args...)...)
+	}
+	cached := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(cacheDir)
+		require.NoError(t, err, `os.ReadDir should succeed`)
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		return names
+	}
+
+	run()
+	require.Len(t, cached(), 1, `compiler should be stored in the cache`)
+
+	require.NoError(t, os.Remove(filepath.Join(dstDir, `object_gen.go`)), `os.Remove should succeed`)
+	run()
+	require.Len(t, cached(), 1, `cached compiler should be reused`)
+	_, err := os.Stat(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `cached compiler should generate code`)
+
+	run(`--var`, `foo=bar`)
+	require.Len(t, cached(), 2, `changing variables should rebuild the compiler`)
+
+	writeFile(t, filepath.Join(srcDir, `schema.go`), schemaSrc(`Title`))
+	run()
+	require.Len(t, cached(), 3, `changing schemas should rebuild the compiler`)
+	generated, err := os.ReadFile(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `os.ReadFile should succeed`)
+	require.Contains(t, string(generated), `func (v *Object) GetTitle() string`, `code should be generated from the new schema`)
+
+	writeFile(t, filepath.Join(srcDir, `names`, `names.go`), namesSrc(`Summary`))
+	run()
+	require.Len(t, cached(), 4, `changing packages imported by schemas should rebuild the compiler`)
+	generated, err = os.ReadFile(filepath.Join(dstDir, `object_gen.go`))
+	require.NoError(t, err, `os.ReadFile should succeed`)
+	require.Contains(t, string(generated), `func (v *Object) GetSummary() string`, `code should be generated from the new package`)
+}
+
+func TestNoSchemas(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+// Object forgot to embed schema.Base
+type Object struct {
+	Name string
+}
+`)
+
+	var app gen.App
+	err := app.Run([]string{`sketch`, `--dst-dir`, t.TempDir(), srcDir})
+	require.Error(t, err, `app.Run should fail`)
+	require.Contains(t, err.Error(), fmt.Sprintf(`could not find any schemas in %q`, srcDir), `error should contain the scanned directory`)
+	require.Contains(t, err.Error(), `embed schema.Base`, `error should explain how to declare schemas`)
+}
+
+func TestMultiplePackages(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+}
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, `stray.go`), []byte("package main\n\nfunc main() {}\n"), 0644), `writing stray file should succeed`)
+
+	var app gen.App
+	err := app.Run([]string{`sketch`, `--dst-dir`, t.TempDir(), srcDir})
+	require.Error(t, err, `app.Run should fail`)
+	require.Contains(t, err.Error(), fmt.Sprintf(`found multiple packages in %q (main, sketchtest)`, srcDir), `error should name the packages`)
+
+	// test files in a separate package are not counted
+	require.NoError(t, os.Remove(filepath.Join(srcDir, `stray.go`)), `os.Remove should succeed`)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, `schema_test.go`), []byte("package sketchtest_test\n"), 0644), `writing test file should succeed`)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, `schema.go`), []byte("package sketchtest\n"), 0644), `writing schema should succeed`)
+	err = app.Run([]string{`sketch`, `--dst-dir`, t.TempDir(), srcDir})
+	require.Error(t, err, `app.Run should fail`)
+	require.Contains(t, err.Error(), `could not find any schemas`, `test packages should be ignored`)
+}
+
+func TestParseErrors(t *testing.T) {
+	srcDir := newSketchModule(t, `package sketchtest
+
+import "github.com/lestrrat-go/sketch/schema"
+
+type Object struct {
+	schema.Base
+	Name string =
+}
+
+func (Object) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Name"),
+	)
+}
+`)
+
+	var app gen.App
+	err := app.Run([]string{`sketch`, `--dst-dir`, t.TempDir(), srcDir})
+	require.Error(t, err, `app.Run should fail`)
+	require.Contains(t, err.Error(), filepath.Join(srcDir, `schema.go`)+`:7:`, `error should contain the position of the first error`)
+	require.Contains(t, err.Error(), filepath.Join(srcDir, `schema.go`)+`:11:`, `error should contain the position of subsequent errors`)
+}
@@ -0,0 +1,178 @@
+package gen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFilenames lists the names of the files that are looked up in
+// the schema directory when --config is not specified
+var configFilenames = []string{`sketch.yml`, `sketch.yaml`, `sketch.json`}
+
+// loadConfig reads the configuration file, and applies its values to
+// the flags that were not explicitly specified in the command line.
+//
+// The file consists of a mapping from flag names to their values.
+// The `var` entry is a mapping from variable names to typed values,
+// which is returned separately so that the types are retained.
+func (app *App) loadConfig(c *cli.Context) (map[string]interface{}, error) {
+	filename := c.String(`config`)
+	if filename == "" {
+		for _, dir := range c.Args().Slice() {
+			fn := findConfig(dir)
+			if fn == "" {
+				continue
+			}
+			// Only one set of flags can be in effect, so the file
+			// cannot be picked when there's ambiguity as to which
+			// schema directory it should be read from
+			if c.NArg() != 1 {
+				return nil, fmt.Errorf(`config file %q cannot be used when multiple schema directories are specified (use --config to specify the file explicitly)`, fn)
+			}
+			filename = fn
+		}
+		if filename == "" {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to read config file %q: %w`, filename, err)
+	}
+
+	// YAML is a superset of JSON, so this handles both formats
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf(`failed to parse config file %q: %w`, filename, err)
+	}
+
+	flags := make(map[string]cli.Flag)
+	for _, flag := range c.App.Flags {
+		for _, name := range flag.Names() {
+			flags[name] = flag
+		}
+	}
+
+	baseDir := filepath.Dir(filename)
+	var vars map[string]interface{}
+	for name, value := range cfg {
+		if name == `var` {
+			v, err := configVariables(value)
+			if err != nil {
+				return nil, fmt.Errorf(`invalid value for %q in config file %q: %w`, name, filename, err)
+			}
+			vars = v
+			continue
+		}
+
+		flag, ok := flags[name]
+		if !ok || name == `config` {
+			return nil, fmt.Errorf(`unknown option %q in config file %q`, name, filename)
+		}
+
+		// flags specified in the command line take precedence
+		if c.IsSet(name) {
+			continue
+		}
+
+		values, err := configFlagValues(flag, value)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid value for %q in config file %q: %w`, name, filename, err)
+		}
+
+		for _, v := range values {
+			// paths are relative to the config file
+			switch name {
+			case `tmpl-dir`, `t`, `dst-dir`, `d`, `header-file`, `cache-dir`:
+				if !filepath.IsAbs(v) {
+					v = filepath.Join(baseDir, v)
+				}
+			}
+			if err := c.Set(name, v); err != nil {
+				return nil, fmt.Errorf(`failed to set %q from config file %q: %w`, name, filename, err)
+			}
+		}
+	}
+	app.Infof(`👉 Loaded config file %q`, filename)
+	return vars, nil
+}
+
+// findConfig returns the name of the configuration file in dir, or
+// the empty string if there is none
+func findConfig(dir string) string {
+	for _, name := range configFilenames {
+		fn := filepath.Join(dir, name)
+		if _, err := os.Stat(fn); err == nil {
+			return fn
+		}
+	}
+	return ""
+}
+
+// configFlagValues converts a value found in the configuration file
+// into the string representation(s) accepted by the flag
+func configFlagValues(flag cli.Flag, value interface{}) ([]string, error) {
+	switch flag.(type) {
+	case *cli.BoolFlag:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf(`expected bool (got %T)`, value)
+		}
+		return []string{strconv.FormatBool(b)}, nil
+	case *cli.StringFlag:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf(`expected string (got %T)`, value)
+		}
+		return []string{s}, nil
+	case *cli.StringSliceFlag:
+		switch value := value.(type) {
+		case string:
+			return []string{value}, nil
+		case []interface{}:
+			list := make([]string, len(value))
+			for i, elem := range value {
+				s, ok := elem.(string)
+				if !ok {
+					return nil, fmt.Errorf(`expected list of strings (got %T in element %d)`, elem, i)
+				}
+				list[i] = s
+			}
+			return list, nil
+		default:
+			return nil, fmt.Errorf(`expected string or list of strings (got %T)`, value)
+		}
+	default:
+		return nil, fmt.Errorf(`unsupported flag type %T`, flag)
+	}
+}
+
+// configVariables converts the `var` entry in the configuration file
+// into variables. Like --var, only string, bool, and int values
+// are supported
+func configVariables(value interface{}) (map[string]interface{}, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`expected mapping (got %T)`, value)
+	}
+
+	vars := make(map[string]interface{}, len(m))
+	for name, v := range m {
+		switch v := v.(type) {
+		case string, bool:
+			vars[name] = v
+		case int:
+			// --var=name=value:int produces int64
+			vars[name] = int64(v)
+		default:
+			return nil, fmt.Errorf(`unhandled variable type %T for %q`, v, name)
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,197 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+const testConfig = `with-yaml: true
+accessor-style: comma-ok
+exclude-symbol:
+  - ^object\.method\.Clone$
+tmpl-dir:
+  - templates
+var:
+  foo: bar
+  count: 3
+  enabled: true
+`
+
+// makeCLIVariables creates the variables from the command line flags,
+// in the same way as RunMain
+func makeCLIVariables(app *App, c *cli.Context, cfgVars map[string]interface{}) (map[string]interface{}, error) {
+	opts, err := optionsFromCLI(c, cfgVars)
+	if err != nil {
+		return nil, err
+	}
+	return app.makeVariables(opts)
+}
+
+func runMakeVariables(t *testing.T, args ...string) map[string]interface{} {
+	t.Helper()
+
+	var app App
+	var variables map[string]interface{}
+	cliapp := app.newCLI()
+	cliapp.Action = func(c *cli.Context) error {
+		cfgVars, err := app.loadConfig(c)
+		if err != nil {
+			return err
+		}
+		variables, err = makeCLIVariables(&app, c, cfgVars)
+		return err
+	}
+	require.NoError(t, cliapp.Run(append([]string{`sketch`}, args...)), `cliapp.Run should succeed`)
+	return variables
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, `sketch.yml`), []byte(testConfig), 0644), `writing config file should succeed`)
+
+	t.Run("config file only", func(t *testing.T) {
+		variables := runMakeVariables(t, dir)
+		require.Equal(t, true, variables[`WithYAML`])
+		require.Equal(t, false, variables[`WithClone`])
+		require.Equal(t, `comma-ok`, variables[`AccessorStyle`])
+		require.Equal(t, []string{`^object\.method\.Clone$`}, variables[`Excludes`])
+		require.Equal(t, []string{filepath.Join(dir, `templates`)}, variables[`UserTemplateDirs`])
+		require.Equal(t, `bar`, variables[`foo`])
+		require.Equal(t, int64(3), variables[`count`])
+		require.Equal(t, true, variables[`enabled`])
+	})
+	t.Run("command line flags take precedence", func(t *testing.T) {
+		variables := runMakeVariables(t, `--with-yaml=false`, `--accessor-style=plain`, `--var`, `foo=baz`, dir)
+		require.Equal(t, false, variables[`WithYAML`])
+		require.Equal(t, `plain`, variables[`AccessorStyle`])
+		require.Equal(t, `baz`, variables[`foo`])
+		require.Equal(t, int64(3), variables[`count`])
+	})
+	t.Run("explicit config file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), `custom.json`)
+		require.NoError(t, os.WriteFile(filename, []byte(`{"with-clone": true, "var": {"foo": "qux"}}`), 0644), `writing config file should succeed`)
+
+		variables := runMakeVariables(t, `--config`, filename, dir)
+		require.Equal(t, true, variables[`WithClone`])
+		require.Equal(t, false, variables[`WithYAML`], `config file in the schema directory should not be read`)
+		require.Equal(t, `qux`, variables[`foo`])
+	})
+	t.Run("multiple schema directories", func(t *testing.T) {
+		var app App
+		cliapp := app.newCLI()
+		cliapp.Action = func(c *cli.Context) error {
+			_, err := app.loadConfig(c)
+			return err
+		}
+		err := cliapp.Run([]string{`sketch`, t.TempDir(), dir})
+		require.Error(t, err, `config file should not be silently ignored`)
+		require.Contains(t, err.Error(), filepath.Join(dir, `sketch.yml`))
+
+		filename := filepath.Join(t.TempDir(), `custom.json`)
+		require.NoError(t, os.WriteFile(filename, []byte(`{"with-clone": true}`), 0644), `writing config file should succeed`)
+		variables := runMakeVariables(t, `--config`, filename, t.TempDir(), dir)
+		require.Equal(t, true, variables[`WithClone`])
+		require.Equal(t, false, variables[`WithYAML`], `config file in the schema directory should not be read`)
+	})
+}
+
+func TestMakeVariablesVar(t *testing.T) {
+	testcases := []struct {
+		Name     string
+		Arg      string
+		Key      string
+		Expected interface{}
+		Error    bool
+	}{
+		{Name: "untyped", Arg: "foo=bar", Key: "foo", Expected: "bar"},
+		{Name: "string", Arg: "foo=bar:string", Key: "foo", Expected: "bar"},
+		{Name: "int", Arg: "count=3:int", Key: "count", Expected: int64(3)},
+		{Name: "bool", Arg: "enabled=true:bool", Key: "enabled", Expected: true},
+		{Name: "value with colons", Arg: "url=http://example.com:8080", Key: "url", Expected: "http://example.com:8080"},
+		{Name: "typed value with colons", Arg: "pair=a:b:string", Key: "pair", Expected: "a:b"},
+		{Name: "unknown type suffix is part of the value", Arg: "foo=bar:float", Key: "foo", Expected: "bar:float"},
+		{Name: "invalid int", Arg: "count=three:int", Error: true},
+		{Name: "invalid bool", Arg: "enabled=yes:bool", Error: true},
+		{Name: "missing value", Arg: "foo=", Error: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			var app App
+			var variables map[string]interface{}
+			cliapp := app.newCLI()
+			cliapp.Action = func(c *cli.Context) error {
+				var err error
+				variables, err = makeCLIVariables(&app, c, nil)
+				return err
+			}
+
+			err := cliapp.Run([]string{`sketch`, `--var`, tc.Arg})
+			if tc.Error {
+				require.Error(t, err, `makeVariables should fail`)
+				return
+			}
+			require.NoError(t, err, `makeVariables should succeed`)
+			require.Equal(t, tc.Expected, variables[tc.Key])
+		})
+	}
+}
+
+func TestAccessorPrefix(t *testing.T) {
+	dir := t.TempDir()
+	variables := runMakeVariables(t, dir)
+	require.Equal(t, `Get`, variables[`AccessorPrefix`], `default prefix should be Get`)
+
+	variables = runMakeVariables(t, `--accessor-prefix`, ``, dir)
+	require.Equal(t, ``, variables[`AccessorPrefix`], `prefix may be empty`)
+
+	variables = runMakeVariables(t, `--accessor-style`, `comma-ok`, dir)
+	require.Equal(t, ``, variables[`AccessorPrefix`], `default prefix should be empty for comma-ok accessors`)
+
+	var app App
+	cliapp := app.newCLI()
+	cliapp.Action = func(c *cli.Context) error {
+		_, err := makeCLIVariables(&app, c, nil)
+		return err
+	}
+	require.Error(t, cliapp.Run([]string{`sketch`, `--accessor-prefix`, `Get-`}), `makeVariables should fail for invalid identifiers`)
+}
+
+func TestKeyNameSuffix(t *testing.T) {
+	dir := t.TempDir()
+	variables := runMakeVariables(t, dir)
+	require.Equal(t, `Key`, variables[`KeyNameSuffix`], `default suffix should be Key`)
+
+	variables = runMakeVariables(t, `--key-name-suffix`, ``, dir)
+	require.Equal(t, ``, variables[`KeyNameSuffix`], `suffix may be empty`)
+}
+
+func TestHeader(t *testing.T) {
+	t.Run("comment lines", func(t *testing.T) {
+		require.Equal(t, "// Copyright\n//\n// Licensed under MIT\n\n", commentHeader("Copyright\n\nLicensed under MIT\n"))
+		require.Equal(t, "// already a comment\n// not a comment\n\n", commentHeader("// already a comment\nnot a comment"))
+		require.Equal(t, "/*\n  block\n*/\n\n", commentHeader("/*\n  block\n*/\n"))
+	})
+	t.Run("header file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, `LICENSE.txt`), []byte("Copyright\n"), 0644), `writing header file should succeed`)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, `sketch.yml`), []byte("header-file: LICENSE.txt\n"), 0644), `writing config file should succeed`)
+
+		variables := runMakeVariables(t, dir)
+		require.Equal(t, "// Copyright\n\n", variables[`Header`], `header file should be relative to the config file`)
+	})
+	t.Run("header and header file", func(t *testing.T) {
+		var app App
+		cliapp := app.newCLI()
+		cliapp.Action = func(c *cli.Context) error {
+			_, err := makeCLIVariables(&app, c, nil)
+			return err
+		}
+		require.Error(t, cliapp.Run([]string{`sketch`, `--header`, `foo`, `--header-file`, `bar`}), `makeVariables should fail`)
+	})
+}
@@ -0,0 +1,91 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// errOutOfDate is returned when --diff is specified and the generated
+// files differ from the files found in the destination directory
+var errOutOfDate = errors.New(`generated files are not up to date`)
+
+// diffGenerated compares the files generated under writeDir against
+// the corresponding files under dstDir, and writes a unified diff for
+// each file that differs. Both sides are formatted using gofmt before
+// being compared, so that whitespace-only differences are ignored.
+//
+// Returns true if any differences were found.
+func diffGenerated(dst io.Writer, writeDir, dstDir string) (bool, error) {
+	var found bool
+	err := filepath.WalkDir(writeDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(writeDir, path)
+		if err != nil {
+			return fmt.Errorf(`failed to get relative path from %q to %q: %w`, writeDir, path, err)
+		}
+
+		generated, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf(`failed to read %q: %w`, path, err)
+		}
+
+		fromFile := filepath.Join(dstDir, rel)
+		current, err := os.ReadFile(fromFile)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf(`failed to read %q: %w`, fromFile, err)
+			}
+			// The file does not exist yet, so everything is an addition
+			current = nil
+			fromFile = os.DevNull
+		}
+
+		current = gofmtSource(current)
+		generated = gofmtSource(generated)
+		if bytes.Equal(current, generated) {
+			return nil
+		}
+
+		found = true
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(current)),
+			B:        difflib.SplitLines(string(generated)),
+			FromFile: fromFile,
+			ToFile:   filepath.Join(dstDir, rel),
+			Context:  3,
+		})
+		if err != nil {
+			return fmt.Errorf(`failed to compute diff for %q: %w`, rel, err)
+		}
+		fmt.Fprint(dst, diff)
+		return nil
+	})
+	return found, err
+}
+
+// gofmtSource formats the Go source code. If the source cannot be
+// formatted (e.g. it is not valid Go code), it is returned as is.
+func gofmtSource(src []byte) []byte {
+	if len(src) == 0 {
+		return src
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return src
+	}
+	return formatted
+}
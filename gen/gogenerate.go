@@ -0,0 +1,115 @@
+package gen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// goGenerateSkipFlags lists the flags that are not reproduced in the
+// //go:generate directive, as they only affect how a particular run is
+// performed, and not the code that is generated
+var goGenerateSkipFlags = map[string]struct{}{
+	`verbose`:       {},
+	`quiet`:         {},
+	`diff`:          {},
+	`dry-run`:       {},
+	`watch`:         {},
+	`list-schemas`:  {},
+	`cache-dir`:     {},
+	`goproxy`:       {},
+	`mod-mode`:      {},
+	`remove-tmpdir`: {},
+	`dev-mode`:      {},
+	`dev-path`:      {},
+	`dst-dir`:       {},
+}
+
+// goGeneratePathFlags lists the flags whose values are paths. They are
+// rewritten to be relative to the destination directory, which is where
+// `go generate` runs the directive
+var goGeneratePathFlags = map[string]struct{}{
+	`config`:      {},
+	`header-file`: {},
+	`tmpl-dir`:    {},
+}
+
+// goGenerateDirective returns the command that is placed after
+// //go:generate, so that `go generate` reproduces the code generated
+// from srcDir into dstDir. dstDir must be an absolute path.
+func goGenerateDirective(c *cli.Context, srcDir, dstDir string) (string, error) {
+	args := []string{`go`, `run`, `github.com/lestrrat-go/sketch/cmd/sketch`}
+	for _, flag := range c.App.Flags {
+		name := flag.Names()[0]
+		if _, ok := goGenerateSkipFlags[name]; ok || !c.IsSet(name) {
+			continue
+		}
+
+		var values []string
+		switch flag.(type) {
+		case *cli.BoolFlag:
+			if c.Bool(name) {
+				args = append(args, `--`+name)
+			} else {
+				args = append(args, `--`+name+`=false`)
+			}
+			continue
+		case *cli.StringFlag:
+			values = []string{c.String(name)}
+		case *cli.StringSliceFlag:
+			values = c.StringSlice(name)
+		default:
+			return "", fmt.Errorf(`unsupported flag type %T for %q`, flag, name)
+		}
+
+		for _, v := range values {
+			if _, ok := goGeneratePathFlags[name]; ok {
+				rel, err := relPath(dstDir, v)
+				if err != nil {
+					return "", err
+				}
+				v = rel
+			}
+			args = append(args, `--`+name+`=`+v)
+		}
+	}
+
+	rel, err := relPath(dstDir, srcDir)
+	if err != nil {
+		return "", err
+	}
+	args = append(args, rel)
+
+	for i, arg := range args {
+		args[i] = quoteGoGenerateArg(arg)
+	}
+	return strings.Join(args, ` `), nil
+}
+
+// relPath returns path relative to base, after resolving it against
+// the current directory
+func relPath(base, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf(`failed to get absolute path for %q: %w`, path, err)
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil {
+		return "", fmt.Errorf(`failed to get relative path from %q to %q: %w`, base, abs, err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// quoteGoGenerateArg quotes arg if `go generate` would otherwise split
+// or interpret it. Quoted arguments use Go string syntax. As `go generate`
+// expands environment variables, dollar signs (e.g. in regular
+// expressions) are written as $DOLLAR, which it expands to "$"
+func quoteGoGenerateArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"'`\\") {
+		arg = strconv.Quote(arg)
+	}
+	return strings.ReplaceAll(arg, `$`, `${DOLLAR}`)
+}
@@ -0,0 +1,50 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestGoGenerateDirective(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, `schema`)
+	dstDir := filepath.Join(dir, `out`)
+	wd, err := os.Getwd()
+	require.NoError(t, err, `os.Getwd should succeed`)
+	require.NoError(t, os.Chdir(dir), `os.Chdir should succeed`)
+	defer os.Chdir(wd)
+
+	var app App
+	var directive string
+	cliapp := app.newCLI()
+	cliapp.Action = func(c *cli.Context) error {
+		var err error
+		directive, err = goGenerateDirective(c, c.Args().Get(0), dstDir)
+		return err
+	}
+	require.NoError(t, cliapp.Run([]string{
+		`sketch`,
+		`--verbose`,
+		`--emit-go-generate`,
+		`--with-has-methods=false`,
+		`--with-yaml`,
+		`--dst-dir`, dstDir,
+		`--tmpl-dir`, `templates`,
+		`--header`, `Copyright 2024 Example`,
+		`--exclude-symbol`, `^object\.method\.Clone$`,
+		srcDir,
+	}), `cliapp.Run should succeed`)
+
+	require.Equal(t, `go run github.com/lestrrat-go/sketch/cmd/sketch`+
+		` "--header=Copyright 2024 Example"`+
+		` --emit-go-generate`+
+		` --with-has-methods=false`+
+		` --with-yaml`+
+		` --tmpl-dir=../templates`+
+		` "--exclude-symbol=^object\\.method\\.Clone${DOLLAR}"`+
+		` ../schema`, directive)
+}
@@ -0,0 +1,165 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// listedField describes a field reported by --list-schemas. It must be
+// kept in sync with the type of the same name in the "compiler/list.go"
+// template
+type listedField struct {
+	Object   string `json:"object"`
+	Field    string `json:"field"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	JSON     string `json:"json"`
+}
+
+// ListSchemas writes a table of the schemas found in each of the schema
+// directories specified in opts and their fields to dst. Only the options
+// that affect how schemas are found and how the program that reads them
+// is built (e.g. ExcludeSchemas, GoProxy, ModMode, and DevPath) are used.
+//
+// As the fields are declared by calling Fields() on each schema, a small
+// program that imports the schema package is still built and run, but
+// unlike the compiler it does not contain any templates, and no code is
+// generated.
+func (app *App) ListSchemas(dst io.Writer, opts Options) error {
+	if len(opts.SrcDirs) == 0 {
+		return fmt.Errorf(`at least one schema directory must be supplied`)
+	}
+	if err := app.prepare(opts); err != nil {
+		return err
+	}
+
+	globals, err := app.makeVariables(opts)
+	if err != nil {
+		return err
+	}
+
+	var list []listedField
+	for _, srcDir := range opts.SrcDirs {
+		absSrcDir, err := filepath.Abs(srcDir)
+		if err != nil {
+			return fmt.Errorf(`failed to get absolute path for %q: %w`, srcDir, err)
+		}
+
+		fields, err := app.listFields(opts, globals, absSrcDir)
+		if err != nil {
+			return fmt.Errorf(`failed to list schemas in schema directory %q: %w`, srcDir, err)
+		}
+		list = append(list, fields...)
+	}
+
+	w := tabwriter.NewWriter(dst, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OBJECT\tFIELD\tTYPE\tREQUIRED\tJSON")
+	for _, f := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", f.Object, f.Field, f.Type, f.Required, f.JSON)
+	}
+	return w.Flush()
+}
+
+// listFields builds and runs the program generated from the
+// "compiler/list.go" template for the schemas declared in srcDir, and
+// returns the fields that it reports. srcDir must be an absolute path.
+func (app *App) listFields(opts Options, globals map[string]interface{}, srcDir string) ([]listedField, error) {
+	tmpDir, err := os.MkdirTemp("", "sketch-*")
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create temporary directory: %w`, err)
+	}
+	defer func() {
+		if opts.KeepTmpDir {
+			app.Infof(`👉 NOT removing temporary working directory %q`, tmpDir)
+			return
+		}
+
+		app.Infof(`👉 Removing temporary working directory %q`, tmpDir)
+		os.RemoveAll(tmpDir)
+	}()
+	app.Infof(`👉 Created temporary working directory %q`, tmpDir)
+
+	variables, err := app.moduleVariables(globals, srcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := genCtx{
+		goProxy:   opts.GoProxy,
+		modMode:   opts.ModMode,
+		srcDir:    srcDir,
+		tmpDir:    tmpDir,
+		variables: variables,
+	}
+
+	schemas, err := app.extractStructs(&ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(schemas) == 0 {
+		return nil, app.errNoSchemas(srcDir)
+	}
+
+	tmpl, err := template.ParseFS(embedded, "tmpl/compiler.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf(`failed to compile template: %w`, err)
+	}
+
+	if err := app.generateGoMod(&ctx, tmpl); err != nil {
+		return nil, fmt.Errorf(`failed to generate go.mod: %w`, err)
+	}
+
+	variables[`Schemas`] = schemas
+	var src bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&src, "compiler/list.go", variables); err != nil {
+		return nil, fmt.Errorf(`failed to execute template "list.go": %w`, err)
+	}
+	dstpath := filepath.Join(tmpDir, `main.go`)
+	if err := os.WriteFile(dstpath, src.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf(`failed to write to %q: %w`, dstpath, err)
+	}
+
+	app.Infof(`👉 Running "go mod tidy"`)
+	cmd := ctx.goCommand(tmpDir, "mod", "tidy")
+	cmd.Stderr = app.logWriter(LogLevelDefault)
+	cmd.Stdout = app.logWriter(LogLevelVerbose)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf(`failed to run go mod tidy: %w`, err)
+	}
+
+	if ctx.modMode == "vendor" {
+		app.Infof(`👉 Running "go mod vendor"`)
+		cmd = ctx.goCommand(tmpDir, "mod", "vendor")
+		cmd.Stderr = app.logWriter(LogLevelDefault)
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf(`failed to run go mod vendor: %w`, err)
+		}
+	}
+
+	args := []string{"run"}
+	if ctx.modMode != "" {
+		args = append(args, "-mod="+ctx.modMode)
+	}
+	args = append(args, ".")
+	var out bytes.Buffer
+	app.Infof(`👉 Running "go %s"`, strings.Join(args, " "))
+	cmd = ctx.goCommand(tmpDir, args...)
+	cmd.Stderr = app.logWriter(LogLevelDefault)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf(`failed to run go run: %w`, err)
+	}
+
+	var fields []listedField
+	if err := json.Unmarshal(out.Bytes(), &fields); err != nil {
+		return nil, fmt.Errorf(`failed to decode the list of fields: %w`, err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,125 @@
+package gen
+
+// Options specifies how App.Generate generates code. Most fields
+// correspond to the command line flags of the same names.
+//
+// The zero values of AccessorPrefix, KeyNameSuffix, and GenerateHasMethods
+// differ from the defaults of the command line flags, so Options should
+// be created via DefaultOptions, and then modified as needed.
+type Options struct {
+	// SrcDirs lists the schema directories to generate code from
+	SrcDirs []string
+	// DstDir is the directory that the generated files are written to
+	// (default: the current directory). It must be empty when multiple
+	// schema directories are given, in which case the files are written
+	// to the parent directory of each schema directory
+	DstDir string
+	// TemplateDirs lists the directories containing templates provided
+	// by the user (--tmpl-dir)
+	TemplateDirs []string
+	// Variables are passed to the templates (--var)
+	Variables map[string]interface{}
+	// ExcludeSchemas lists the patterns of the names of the schemas
+	// that are not generated (--exclude-schema)
+	ExcludeSchemas []string
+	// ExcludeSymbols lists the patterns of the symbols that are not
+	// generated (--exclude-symbol)
+	ExcludeSymbols []string
+	// ExcludeFields lists the patterns of the fields that are not
+	// generated (--exclude-field)
+	ExcludeFields []string
+	// RenameSymbols maps the names of symbols to the names that they
+	// are generated as (--rename-symbol)
+	RenameSymbols map[string]string
+
+	// AccessorStyle is either "plain" or "comma-ok" (default: "plain")
+	AccessorStyle string
+	// AccessorPrefix is prepended to the names of the accessors. It must
+	// be changed (e.g. to the empty string) when AccessorStyle is "comma-ok"
+	AccessorPrefix string
+	// KeyNameSuffix is appended to the names of the constants containing
+	// the JSON field names
+	KeyNameSuffix string
+	// Format is either "gofmt", "goimports", or "none" (default: "gofmt")
+	Format string
+	// Header is inserted at the beginning of each generated file. Lines
+	// that are not comments are prefixed with "//"
+	Header string
+	// BinaryFormat is either "json" or "gob" (default: "json")
+	BinaryFormat string
+
+	GenerateHasMethods bool // --with-has-methods
+	WithKeyNamePrefix  bool // --with-key-name-prefix
+	BuildersSameFile   bool // --builders-same-file
+	StructTags         bool // --struct-tags
+	WalkSkipSecret     bool // --walk-skip-secret
+	CBORDeterministic  bool // --cbor-deterministic
+	WithAsMap          bool // --with-asmap
+	WithBinary         bool // --with-binary
+	WithCBOR           bool // --with-cbor
+	WithClone          bool // --with-clone
+	WithConstructor    bool // --with-constructor
+	WithDiff           bool // --with-diff
+	WithEqual          bool // --with-equal
+	WithForm           bool // --with-form
+	WithGob            bool // --with-gob
+	WithGraphQL        bool // --with-graphql
+	WithInterface      bool // --with-interface
+	WithJSONSchema     bool // --with-jsonschema
+	WithLogMarshal     bool // --with-logmarshal
+	WithMerge          bool // --with-merge
+	WithMsgpack        bool // --with-msgpack
+	WithOpenAPI        bool // --with-openapi
+	WithOptions        bool // --with-options
+	WithPtrAccessors   bool // --with-ptr-accessors
+	WithRegistry       bool // --with-registry
+	WithReset          bool // --with-reset
+	WithSQL            bool // --with-sql
+	WithStrictJSON     bool // --with-strict-json
+	WithStringer       bool // --with-stringer
+	WithTOML           bool // --with-toml
+	WithValidation     bool // --with-validation
+	WithXML            bool // --with-xml
+	WithYAML           bool // --with-yaml
+
+	// GoGenerate returns the command placed after the //go:generate
+	// directive in sketch_gen.go for the given schema directory and
+	// destination directory. No directive is emitted when it is nil
+	GoGenerate func(srcDir, dstDir string) (string, error)
+	// CacheDir is the directory that compilers are cached in (--cache-dir)
+	CacheDir string
+	// GoProxy is the value of GOPROXY used when running the go command
+	// to build the compiler. The environment is used as is when it is
+	// empty (--goproxy)
+	GoProxy string
+	// ModMode is either "mod", "readonly", or "vendor", which is passed
+	// to `go build` via -mod when building the compiler. With "vendor",
+	// the dependencies are vendored in the temporary directory first.
+	// The go command decides when it is empty (--mod-mode)
+	ModMode string
+	// Diff reports the differences against the files in the destination
+	// directory instead of writing them (--diff)
+	Diff bool
+	// DryRun reports the files that would have been written instead of
+	// writing them (--dry-run)
+	DryRun bool
+	// KeepTmpDir leaves the temporary working directory in place, so that
+	// intermediate artifacts can be inspected (--remove-tmpdir=false)
+	KeepTmpDir bool
+	// DevPath is the directory containing the source code of sketch,
+	// which is used instead of the released module (--dev-path)
+	DevPath string
+}
+
+// DefaultOptions returns the Options corresponding to the defaults of
+// the command line flags
+func DefaultOptions() Options {
+	return Options{
+		AccessorStyle:      `plain`,
+		AccessorPrefix:     `Get`,
+		KeyNameSuffix:      `Key`,
+		Format:             `gofmt`,
+		BinaryFormat:       `json`,
+		GenerateHasMethods: true,
+	}
+}
@@ -0,0 +1,125 @@
+package gen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchInterval is the interval at which the schema directories are
+// checked for changes in --watch mode
+var watchInterval = 500 * time.Millisecond
+
+// watchDebounce is the duration for which the schema directories must
+// remain unchanged before the code is regenerated. Editors often write
+// files several times in a row when saving, and we only want to run
+// once for all of them
+var watchDebounce = 300 * time.Millisecond
+
+// watchState records the modification time and the size of each
+// Go source file in the watched directories
+type watchState map[string]watchEntry
+
+type watchEntry struct {
+	modTime time.Time
+	size    int64
+}
+
+func (s watchState) equal(other watchState) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for path, entry := range s {
+		otherEntry, ok := other[path]
+		if !ok || !entry.modTime.Equal(otherEntry.modTime) || entry.size != otherEntry.size {
+			return false
+		}
+	}
+	return true
+}
+
+// scanWatchState collects the state of the Go source files in dirs.
+// Files that cannot be read are ignored, as they are most likely
+// being replaced by an editor
+func scanWatchState(dirs []string) watchState {
+	state := make(watchState)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), `.go`) {
+				continue
+			}
+			fi, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			state[filepath.Join(dir, entry.Name())] = watchEntry{
+				modTime: fi.ModTime(),
+				size:    fi.Size(),
+			}
+		}
+	}
+	return state
+}
+
+// watch calls run once, and then again every time a Go source file in
+// dirs is created, modified, or removed, until ctx is canceled. The
+// result of each run is reported along with a timestamp. Errors from run
+// do not stop the watch, as they are expected to be fixed by the next
+// change to the schema.
+//
+// Changes are detected by periodically comparing the modification times
+// and the sizes of the files, which does not require any platform
+// specific notification mechanism.
+func (app *App) watch(ctx context.Context, dirs []string, run func() error) error {
+	report := func() {
+		now := time.Now().Format(`15:04:05`)
+		if err := run(); err != nil {
+			app.Printf("[%s] ❌ failed to generate code: %s", now, err)
+			return
+		}
+		app.Printf("[%s] ✅ generated code", now)
+	}
+
+	prev := scanWatchState(dirs)
+	report()
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current := scanWatchState(dirs)
+		if current.equal(prev) {
+			continue
+		}
+
+		// wait until the files stop changing
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(watchDebounce):
+			}
+
+			next := scanWatchState(dirs)
+			if next.equal(current) {
+				break
+			}
+			current = next
+		}
+
+		app.Infof(`👉 Detected changes in schema directories`)
+		prev = current
+		report()
+	}
+}
@@ -0,0 +1,67 @@
+package gen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	interval, debounce := watchInterval, watchDebounce
+	watchInterval, watchDebounce = 10*time.Millisecond, 10*time.Millisecond
+	defer func() { watchInterval, watchDebounce = interval, debounce }()
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, `schema.go`)
+	require.NoError(t, os.WriteFile(fn, []byte("package schema\n"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	var app App
+	go func() {
+		done <- app.watch(ctx, []string{dir}, func() error {
+			runs <- struct{}{}
+			return nil
+		})
+	}()
+
+	waitRun := func(msg string) {
+		t.Helper()
+		select {
+		case <-runs:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, msg)
+		}
+	}
+
+	waitRun(`code should be generated when the watch starts`)
+
+	// files other than Go source files are not watched
+	require.NoError(t, os.WriteFile(filepath.Join(dir, `README`), []byte("hello"), 0644))
+	select {
+	case <-runs:
+		require.FailNow(t, `changes to non-Go files should be ignored`)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(fn, []byte("package schema\n\ntype Foo struct{}\n"), 0644))
+	waitRun(`code should be regenerated when a file is modified`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, `other.go`), []byte("package schema\n"), 0644))
+	waitRun(`code should be regenerated when a file is added`)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, `watch should stop when the context is canceled`)
+	}
+}
@@ -7,18 +7,18 @@ require (
 	github.com/lestrrat-go/byteslice v0.0.0-20221007013458-55ef0707fc94
 	github.com/lestrrat-go/multifs v0.0.0-20220929095432-73523184bb48
 	github.com/lestrrat-go/xstrings v0.0.0-20210804220435-4dd8b234342b
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.8.0
 	github.com/urfave/cli/v2 v2.16.3
 	golang.org/x/mod v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/lestrrat-go/option v1.0.0 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
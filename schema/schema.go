@@ -1,12 +1,21 @@
 package schema
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
 	"github.com/lestrrat-go/byteslice"
+	"github.com/lestrrat-go/sketch/duration"
+	"github.com/lestrrat-go/sketch/epoch"
 	"github.com/lestrrat-go/xstrings"
 )
 
@@ -36,6 +45,7 @@ type Interface interface {
 	Fields() []*FieldSpec
 	Comment() string
 	KeyNamePrefix() string
+	KeyNameSuffix() string
 	GetKeyName(string) string
 }
 
@@ -90,8 +100,35 @@ func (b Base) BoolVar(name string) bool {
 	return false
 }
 
+// AllSchemas returns all of the schemas that are being processed in the
+// same run, including the schema itself. It allows templates to refer to
+// other schemas, for example to generate a lookup table for all objects.
+func (b Base) AllSchemas() []Interface {
+	v, ok := b.Variables[`DefaultAllSchemas`]
+	if ok {
+		if converted, ok := v.([]Interface); ok {
+			return converted
+		}
+	}
+	return nil
+}
+
+// AllTypes returns the types of the fields of all of the schemas that
+// are being processed in the same run, keyed by the value of their
+// `GetName` method. It allows templates to query the properties of
+// types that are declared in other schemas.
+func (b Base) AllTypes() map[string]*TypeSpec {
+	v, ok := b.Variables[`DefaultAllTypes`]
+	if ok {
+		if converted, ok := v.(map[string]*TypeSpec); ok {
+			return converted
+		}
+	}
+	return nil
+}
+
 func (b Base) GetKeyName(fieldName string) string {
-	return b.KeyNamePrefix() + fieldName + `Key`
+	return b.KeyNamePrefix() + fieldName + b.KeyNameSuffix()
 }
 
 // GenerateSymbol should return true if the given method is allowed to be
@@ -112,6 +149,26 @@ func (b Base) GenerateSymbol(s string) bool {
 	return true
 }
 
+// GenerateField should return true if the field with the given name
+// is allowed to be generated. Fields that are not allowed are omitted
+// from the object entirely, including the accessors, the builder, and
+// the JSON representation.
+//
+// By default all fields are allowed, unless they are excluded via the
+// `--exclude-field` command line option. Users may configure this on a
+// per-object basis by providing their own `GenerateField` method.
+func (b Base) GenerateField(s string) bool {
+	m, ok := b.Variables["DefaultGenerateField"]
+	if !ok {
+		return true
+	}
+
+	if m, ok := m.(func(string) bool); ok {
+		return m(s)
+	}
+	return true
+}
+
 // SymbolName takes an internal name like "object.method.Foo" and returns
 // the actual symbol name
 func (b Base) SymbolName(s string) string {
@@ -163,6 +220,14 @@ func (b Base) BuilderResultType() string {
 	return b.StringVar(`DefaultBuilderResultType`)
 }
 
+// InterfaceName returns the name of the interface type that is generated
+// when --with-interface is specified. By default a name comprising of the
+// return value from schema's `Name()` method and `Interface` will be
+// used (e.g. "FooInterface").
+func (b Base) InterfaceName() string {
+	return b.StringVar(`DefaultInterfaceName`)
+}
+
 // Package returns the name of the package that a schema belongs to.
 // By default this value is set to the last element of the destination
 // directory. For example, if you are generating files under `/home/lestrrat/foo`,
@@ -182,6 +247,17 @@ func (b Base) FilenameBase() string {
 	return ""
 }
 
+// Filename is used to specify the complete name of the file that the
+// object is generated into (e.g. `object_gen.go`), including the extension.
+// Unlike `FilenameBase`, the `_gen` suffix is not added, and when provided,
+// it takes precedence over `FilenameBase`.
+//
+// Other files generated for the object are named after it: with
+// `object_gen.go`, the builder is generated into `object_gen_builder.go`.
+func (b Base) Filename() string {
+	return ""
+}
+
 // Fields returns the list of fields that should be associated with the
 // schema object. User usually must
 func (Base) Fields() []*FieldSpec {
@@ -189,6 +265,12 @@ func (Base) Fields() []*FieldSpec {
 }
 
 // Imports returns the list of packges to be imported.
+//
+// The packages used by the generated code, and those of the types of the
+// fields declared via Type(), are imported automatically, so only the
+// packages referenced by other means (e.g. types declared via TypeName)
+// need to be listed. The imports are merged, sorted, and deduplicated,
+// and those that the generated file does not reference are removed.
 func (Base) Imports() []string {
 	return []string(nil)
 }
@@ -214,6 +296,460 @@ func (b Base) KeyNamePrefix() string {
 	return b.StringVar(`DefaultKeyNamePrefix`)
 }
 
+// KeyNameSuffix returns the suffix that should be added to key name
+// constants. By default this is `Key`, which results in constants in
+// the form of `FieldName` + `Key`. The default value can be changed
+// via the --key-name-suffix command line option, and may be empty.
+// Users may configure this on a per-object basis by providing their
+// own `KeyNameSuffix` method.
+func (b Base) KeyNameSuffix() string {
+	if v, ok := b.Variables[`DefaultKeyNameSuffix`].(string); ok {
+		return v
+	}
+	return `Key`
+}
+
+// AccessorStyle returns the style of the accessors that should be
+// generated for each field. By default this value is set from the
+// --accessor-style command line option. Users may configure this on a
+// per-object basis by providing their own `AccessorStyle` method.
+//
+// The default style (an empty string or "plain") generates accessors
+// that return the zero value when the field has not been populated.
+// The "comma-ok" style additionally generates `GetXXX` accessors that
+// return the value along with a boolean indicating if the field
+// has been populated.
+func (b Base) AccessorStyle() string {
+	return b.StringVar(`DefaultAccessorStyle`)
+}
+
+// SettersReturnError returns true if `SetXXX` methods should be generated
+// for each field. Setters for fields with constraints (see `MinLen`,
+// `MaxLen`, `Pattern`, `Min`, and `Max`) or types that specify an
+// `AcceptValue` method return an error, and reject invalid values
+// without modifying the object. Setters for other fields cannot fail,
+// and return the object itself so that calls can be chained.
+//
+// By default this method returns false, and no setters are generated.
+// Users may provide their own `SettersReturnError` method to enable them.
+func (Base) SettersReturnError() bool {
+	return false
+}
+
+// AccessorPrefix returns the prefix of the names of the accessors that
+// retrieve the values of the fields. By default the prefix is `Get`, and
+// the accessors are named like `GetName()`. The default value can be
+// changed via the --accessor-prefix command line option, e.g. an empty
+// prefix generates accessors named after the fields (e.g. `Name()`).
+// With --accessor-style=comma-ok, the default prefix is empty, as the
+// `GetXXX()` names are used by the comma-ok accessors. Users may configure
+// this on a per-object basis by providing their own `AccessorPrefix` method.
+func (b Base) AccessorPrefix() string {
+	return b.StringVar(`DefaultAccessorPrefix`)
+}
+
+// GenerateHasMethods returns true if the `HasXXX` methods should be
+// generated for each field. By default this value is set from the
+// --with-has-methods command line option, which is true unless
+// explicitly disabled. Users may configure this on a per-object basis
+// by providing their own `GenerateHasMethods` method, and on a per-field
+// basis via `FieldSpec.HasMethod`.
+func (b Base) GenerateHasMethods() bool {
+	if _, ok := b.Variables[`DefaultGenerateHasMethods`]; !ok {
+		return true
+	}
+	return b.BoolVar(`DefaultGenerateHasMethods`)
+}
+
+// BuildTags returns the build constraints that the file containing the
+// generated object should be built under, e.g. `linux` or `!windows`.
+// When more than one constraint is returned, they are combined using `&&`.
+// By default no constraints are specified. Users may configure this on a
+// per-object basis by providing their own `BuildTags` method.
+func (b Base) BuildTags() []string {
+	return nil
+}
+
+// WithAsMap returns true if the `AsMap` method should be generated
+// for the object. By default this value is set from the --with-asmap
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithAsMap` method.
+func (b Base) WithAsMap() bool {
+	return b.BoolVar(`DefaultWithAsMap`)
+}
+
+// WithConstructor returns true if a `NewXXX` constructor that takes the
+// required fields as arguments should be generated for the object.
+// By default this value is set from the --with-constructor command line
+// option. Users may configure this on a per-object basis by providing
+// their own `WithConstructor` method.
+//
+// This option cannot be used in conjunction with `WithOptions`, as both
+// generate a constructor with the same name.
+func (b Base) WithConstructor() bool {
+	return b.BoolVar(`DefaultWithConstructor`)
+}
+
+// WithGraphQL returns true if a GraphQL type definition should be
+// generated for the object. By default this value is set from the
+// --with-graphql command line option. Users may configure this on a
+// per-object basis by providing their own `WithGraphQL` method.
+func (b Base) WithGraphQL() bool {
+	return b.BoolVar(`DefaultWithGraphQL`)
+}
+
+// WithJSONSchema returns true if a JSON Schema document describing the
+// JSON representation of the object should be generated. By default this
+// value is set from the --with-jsonschema command line option. Users may
+// configure this on a per-object basis by providing their own
+// `WithJSONSchema` method.
+func (b Base) WithJSONSchema() bool {
+	return b.BoolVar(`DefaultWithJSONSchema`)
+}
+
+// WithInterface returns true if an interface type containing the
+// accessor methods should be generated for the object. By default this
+// value is set from the --with-interface command line option. Users may
+// configure this on a per-object basis by providing their own
+// `WithInterface` method.
+//
+// The name of the interface can be configured by providing a
+// custom `InterfaceName` method.
+func (b Base) WithInterface() bool {
+	return b.BoolVar(`DefaultWithInterface`)
+}
+
+// WithMerge returns true if the `Merge` method should be generated
+// for the object. By default this value is set from the --with-merge
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithMerge` method.
+func (b Base) WithMerge() bool {
+	return b.BoolVar(`DefaultWithMerge`)
+}
+
+// WithMsgpack returns true if the `EncodeMsgpack` and `DecodeMsgpack`
+// methods should be generated for the object. By default this value is
+// set from the --with-msgpack command line option. Users may configure
+// this on a per-object basis by providing their own `WithMsgpack` method.
+//
+// The generated code uses "github.com/vmihailenco/msgpack/v5", which is
+// imported automatically, but must be added to the module that contains
+// the generated code.
+func (b Base) WithMsgpack() bool {
+	return b.BoolVar(`DefaultWithMsgpack`)
+}
+
+// BuildersSameFile returns true if the builder (and the functional options,
+// if any) should be generated in the same file as the object. By default
+// builders are generated in a separate file named after the object (e.g.
+// `object_builder_gen.go`), and this value is set from the
+// --builders-same-file command line option. Users may configure this on a
+// per-object basis by providing their own `BuildersSameFile` method.
+func (b Base) BuildersSameFile() bool {
+	return b.BoolVar(`DefaultBuildersSameFile`)
+}
+
+// StructTags returns true if struct tags should be emitted on the fields
+// of the generated struct, for the benefit of tools that read them via
+// reflection. Each field is tagged with its JSON field name, along with
+// the tags specified via `FieldSpec.Tag`. By default this value is set
+// from the --struct-tags command line option. Users may configure this
+// on a per-object basis by providing their own `StructTags` method.
+//
+// The tags do not affect the generated marshalers. As the fields of the
+// generated struct are unexported, `go vet` reports the json tags on
+// them, so the generated files may need to be excluded from it.
+func (b Base) StructTags() bool {
+	return b.BoolVar(`DefaultStructTags`)
+}
+
+// WalkSkipSecret returns true if the generated `Walk` method should not
+// visit the fields marked via `FieldSpec.Secret`. By default this value
+// is set from the --walk-skip-secret command line option. Users may
+// configure this on a per-object basis by providing their own
+// `WalkSkipSecret` method.
+func (b Base) WalkSkipSecret() bool {
+	return b.BoolVar(`DefaultWalkSkipSecret`)
+}
+
+// WithOptions returns true if functional options (`WithXXX` functions)
+// and a `NewXXX` constructor should be generated for the object. By
+// default this value is set from the --with-options command line option.
+// Users may configure this on a per-object basis by providing their own
+// `WithOptions` method.
+//
+// The generated options use the builder, so the builder methods for
+// each field must also be generated.
+func (b Base) WithOptions() bool {
+	return b.BoolVar(`DefaultWithOptions`)
+}
+
+// WithPtrAccessors returns true if `XXXPtr` methods, which return the
+// values of fields as pointers that are nil when the fields are not
+// populated, should be generated for the object, along with the builder
+// methods that take such pointers. By default this value is set from the
+// --with-ptr-accessors command line option. Users may configure this on
+// a per-object basis by providing their own `WithPtrAccessors` method.
+func (b Base) WithPtrAccessors() bool {
+	return b.BoolVar(`DefaultWithPtrAccessors`)
+}
+
+// WithOpenAPI returns true if an OpenAPI 3 component schema describing
+// the JSON representation of the object should be generated. By default
+// this value is set from the --with-openapi command line option. Users
+// may configure this on a per-object basis by providing their own
+// `WithOpenAPI` method.
+func (b Base) WithOpenAPI() bool {
+	return b.BoolVar(`DefaultWithOpenAPI`)
+}
+
+// WithReset returns true if the `Reset` method should be generated
+// for the object. By default this value is set from the --with-reset
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithReset` method.
+func (b Base) WithReset() bool {
+	return b.BoolVar(`DefaultWithReset`)
+}
+
+// WithStringer returns true if the `String` method should be generated
+// for the object. By default this value is set from the --with-stringer
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithStringer` method.
+func (b Base) WithStringer() bool {
+	return b.BoolVar(`DefaultWithStringer`)
+}
+
+// WithValidation returns true if the `Validate` method should be generated
+// for the object. By default this value is set from the --with-validation
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithValidation` method.
+func (b Base) WithValidation() bool {
+	return b.BoolVar(`DefaultWithValidation`)
+}
+
+// ValidateOnBuild returns true if the `Build` method of the builder
+// should call the generated `Validate` method, so that objects that
+// violate the constraints declared in the schema cannot be built.
+// This only has effect when `WithValidation` returns true, and it
+// defaults to true. Users may configure this on a per-object basis
+// by providing their own `ValidateOnBuild` method.
+//
+// `Build` checks for missing required fields before calling
+// `Validate`, so the error for a missing required field is reported
+// by `Build` itself, and is not reported twice.
+func (b Base) ValidateOnBuild() bool {
+	return true
+}
+
+// WithTOML returns true if the `MarshalTOML` and `UnmarshalTOML` methods
+// should be generated for the object. By default this value is set from
+// the --with-toml command line option. Users may configure this on a
+// per-object basis by providing their own `WithTOML` method.
+//
+// The generated code uses "github.com/BurntSushi/toml", which is imported
+// automatically, but must be added to the module that contains the
+// generated code.
+func (b Base) WithTOML() bool {
+	return b.BoolVar(`DefaultWithTOML`)
+}
+
+// WithYAML returns true if the `MarshalYAML` and `UnmarshalYAML` methods
+// should be generated for the object. By default this value is set from
+// the --with-yaml command line option. Users may configure this on a
+// per-object basis by providing their own `WithYAML` method.
+//
+// The generated code uses "gopkg.in/yaml.v3", which is imported
+// automatically, but must be added to the module that contains the
+// generated code.
+func (b Base) WithYAML() bool {
+	return b.BoolVar(`DefaultWithYAML`)
+}
+
+// WithBinary returns true if the `MarshalBinary` and `UnmarshalBinary`
+// methods, which implement encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler, should be generated for the object. By
+// default this value is set from the --with-binary command line option.
+// Users may configure this on a per-object basis by providing their own
+// `WithBinary` method.
+func (b Base) WithBinary() bool {
+	return b.BoolVar(`DefaultWithBinary`)
+}
+
+// BinaryFormat returns the format used by `MarshalBinary`, which is either
+// "json" or "gob". By default this is "json", and the default value can be
+// changed via the --binary-format command line option. Users may configure
+// this on a per-object basis by providing their own `BinaryFormat` method.
+//
+// With "gob", the generated code uses "encoding/gob", which is imported
+// automatically.
+func (b Base) BinaryFormat() string {
+	if v, ok := b.Variables[`DefaultBinaryFormat`].(string); ok && v != "" {
+		return v
+	}
+	return `json`
+}
+
+// WithGob returns true if the `GobEncode` and `GobDecode` methods, which
+// implement gob.GobEncoder and gob.GobDecoder, should be generated for the
+// object. Without them, "encoding/gob" cannot see the values, as they are
+// stored in unexported fields. By default this value is set from the
+// --with-gob command line option. Users may configure this on a per-object
+// basis by providing their own `WithGob` method.
+//
+// The generated code uses "encoding/gob", which is imported automatically.
+func (b Base) WithGob() bool {
+	return b.BoolVar(`DefaultWithGob`)
+}
+
+// WithCBOR returns true if the `MarshalCBOR` and `UnmarshalCBOR` methods
+// should be generated for the object. By default this value is set from
+// the --with-cbor command line option. Users may configure this on a
+// per-object basis by providing their own `WithCBOR` method.
+//
+// The generated code uses "github.com/fxamacker/cbor/v2", which is
+// imported automatically, but must be added to the module that contains
+// the generated code.
+func (b Base) WithCBOR() bool {
+	return b.BoolVar(`DefaultWithCBOR`)
+}
+
+// CBORDeterministic returns true if `MarshalCBOR` should use the core
+// deterministic encoding described in RFC 8949, which sorts map keys
+// so that the same object always produces the same bytes. By default
+// this value is set from the --cbor-deterministic command line option.
+// Users may configure this on a per-object basis by providing their own
+// `CBORDeterministic` method.
+func (b Base) CBORDeterministic() bool {
+	return b.BoolVar(`DefaultCBORDeterministic`)
+}
+
+// WithForm returns true if the `EncodeForm` and `DecodeForm` methods,
+// which convert the object to and from url.Values, should be generated
+// for the object. By default this value is set from the --with-form
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithForm` method.
+//
+// The generated code uses "net/url", which is imported automatically.
+func (b Base) WithForm() bool {
+	return b.BoolVar(`DefaultWithForm`)
+}
+
+// WithLogMarshal returns true if the `MarshalZerologObject` method, which
+// allows the object to be logged using "github.com/rs/zerolog" without
+// going through JSON, should be generated for the object. By default this
+// value is set from the --with-logmarshal command line option. Users may
+// configure this on a per-object basis by providing their own
+// `WithLogMarshal` method.
+//
+// The generated code uses "github.com/rs/zerolog", which is imported
+// automatically, but must be added to the module that contains the
+// generated code.
+func (b Base) WithLogMarshal() bool {
+	return b.BoolVar(`DefaultWithLogMarshal`)
+}
+
+// WithXML returns true if the `MarshalXML` and `UnmarshalXML` methods
+// should be generated for the object. By default this value is set from
+// the --with-xml command line option. Users may configure this on a
+// per-object basis by providing their own `WithXML` method.
+//
+// The generated code uses "encoding/xml", which is imported
+// automatically.
+func (b Base) WithXML() bool {
+	return b.BoolVar(`DefaultWithXML`)
+}
+
+// XMLNamespace returns the XML namespace of the element that represents
+// the object, which is emitted as the `xmlns` attribute by `MarshalXML`.
+// By default no namespace is specified. Users may configure this on a
+// per-object basis by providing their own `XMLNamespace` method.
+func (b Base) XMLNamespace() string {
+	return ""
+}
+
+// WithClone returns true if the `Clone` method should create deep copies
+// of the object. By default this value is set from the --with-clone
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithClone` method.
+//
+// When this is true, `Clone() *Object` is generated instead of the
+// default `Clone(dst interface{}) error`, which only creates a shallow copy.
+func (b Base) WithClone() bool {
+	return b.BoolVar(`DefaultWithClone`)
+}
+
+// WithDiff returns true if the `Diff` method should be generated
+// for the object. By default this value is set from the --with-diff
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithDiff` method.
+//
+// Like `Equal`, the generated code uses the "reflect" package, which is
+// imported automatically.
+func (b Base) WithDiff() bool {
+	return b.BoolVar(`DefaultWithDiff`)
+}
+
+// WithEqual returns true if the `Equal` method should be generated
+// for the object. By default this value is set from the --with-equal
+// command line option. Users may configure this on a per-object basis
+// by providing their own `WithEqual` method.
+//
+// The generated code uses the "reflect" package, which is imported
+// automatically.
+func (b Base) WithEqual() bool {
+	return b.BoolVar(`DefaultWithEqual`)
+}
+
+// WithSQL returns true if the `Scan` and `Value` methods should be
+// generated for the object, so that it can be stored in a single
+// `database/sql` column as JSON. By default this value is set from
+// the --with-sql command line option. Users may configure this on a
+// per-object basis by providing their own `WithSQL` method.
+//
+// The generated code uses the "database/sql/driver" package, which is
+// imported automatically.
+func (b Base) WithSQL() bool {
+	return b.BoolVar(`DefaultWithSQL`)
+}
+
+// StrictJSON returns true if the generated `UnmarshalJSON` method should
+// return an error when it encounters a JSON key that is not declared
+// in the schema. By default this value is set from the --with-strict-json
+// command line option. Users may configure this on a per-object basis
+// by providing their own `StrictJSON` method.
+//
+// This option cannot be used in conjunction with `UnknownFieldSink`.
+func (b Base) StrictJSON() bool {
+	return b.BoolVar(`DefaultStrictJSON`)
+}
+
+// UnknownFieldSink returns the name of the field where JSON fields that
+// are not declared in the schema should be stored when decoding JSON.
+// By default this is empty, and such fields are stored in the same
+// storage as fields set via `Set()`.
+//
+// The field must be declared as an extension field of type
+// `map[string]json.RawMessage`, for example:
+//
+//	schema.Field(`UnknownFields`, schema.TypeName(`map[string]json.RawMessage`)).IsExtension(true)
+//
+// The raw bytes are stored as-is, and are written back verbatim
+// when encoding the object into JSON.
+func (b Base) UnknownFieldSink() string {
+	return b.StringVar(`DefaultUnknownFieldSink`)
+}
+
+// TextRepresentation returns the name of the field that represents the
+// object as text. By default this is empty. When specified, `MarshalText`
+// and `UnmarshalText` methods that delegate to the field are generated,
+// which allows the object to be used as map keys in JSON, for example.
+//
+// The apparent type of the field must be `string`, `[]byte`, or one of
+// the integer types.
+func (b Base) TextRepresentation() string {
+	return b.StringVar(`DefaultTextRepresentation`)
+}
+
 // TypeSpec is used to store information about a type, and contains
 // various pieces of hints to generate objects/builders.
 //
@@ -241,12 +777,49 @@ type TypeSpec struct {
 	getValueMethodName    string
 	initArgStyle          InitializerArgumentStyle
 	supportsLen           bool
+	supportsConst         bool
 	zeroVal               string
 	isInterface           bool
+	isSlice               bool
+	isArray               bool
+	isMap                 bool
+	isComparable          bool
+	imports               []string
 	interfaceDecoder      string
-}
+	cloneMethodName       string
+	equalMethodName       string
+	resetMethodName       string
+	sqlType               string
+	sqlScannable          bool
+	graphQLType           string
+	inferredGraphQLType   string
+	jsonSchemaType        string
+	inferredJSONSchema    map[string]interface{}
+	openAPIType           string
+	openAPIFormat         string
+	jsonEncoding          string
+	textMarshaler         bool
+	emptyStringAsNull     bool
+	mapKey                string
+	mapElement            string
+}
+
+var reImportPathPrefix = regexp.MustCompile(`(?:[\w.~-]+/)+`)
 
 func typeName(rv reflect.Type) string {
+	// Named types (e.g. json.RawMessage) must be referred to by their
+	// names, not by their underlying types
+	if rv.Name() != "" {
+		name := rv.String()
+		// Type arguments of generic types are qualified by their full
+		// import paths (e.g. `Set[example.com/pkg.Foo]`), which need
+		// to be converted to package names
+		if strings.Contains(name, `[`) {
+			name = reImportPathPrefix.ReplaceAllString(name, ``)
+		}
+		return name
+	}
+
 	var name string
 	switch rv.Kind() {
 	case reflect.Ptr:
@@ -275,8 +848,14 @@ func Type(v interface{}) *TypeSpec {
 
 	typ := typeName(rv)
 
-	var isInterface bool
+	var isInterface, isSlice, isArray, isMap bool
 	switch rv.Kind() {
+	case reflect.Slice:
+		isSlice = true
+	case reflect.Array:
+		isArray = true
+	case reflect.Map:
+		isMap = true
 	case reflect.String:
 		if v.(string) != "" {
 			panic(fmt.Sprintf(`schema.Type received a non-empty string value %q. possible misuse of schema.TypeName?`, v))
@@ -287,11 +866,13 @@ func Type(v interface{}) *TypeSpec {
 
 	var ptrType string
 	var rawType string
+	rawRV := rv
 	switch rv.Kind() {
 	case reflect.Ptr:
-		rawType = typeName(rv.Elem())
+		rawRV = rv.Elem()
+		rawType = typeName(rawRV)
 		ptrType = typ
-	case reflect.Slice, reflect.Interface, reflect.Array:
+	case reflect.Slice, reflect.Interface:
 		rawType = typ
 		ptrType = typ
 	default:
@@ -321,9 +902,15 @@ func Type(v interface{}) *TypeSpec {
 
 	// The initialization style depends on the apparent
 	element := "sketch.UnknownType" // so it's easier to see
-	if apparentType.Kind() == reflect.Slice {
+	var mapKey, mapElement string
+	switch apparentType.Kind() {
+	case reflect.Slice:
 		element = typeName(apparentType.Elem())
 		initArgStyle = InitializerArgumentAsSlice
+	case reflect.Map:
+		mapKey = typeName(apparentType.Key())
+		mapElement = typeName(apparentType.Elem())
+		element = mapElement
 	}
 
 	// Check if the storage type supports len() operation
@@ -333,6 +920,36 @@ func Type(v interface{}) *TypeSpec {
 		supportsLen = true
 	}
 
+	// Values of basic types (booleans, strings, and numbers) can be
+	// declared as Go constants
+	var supportsConst bool
+	switch apparentType.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		supportsConst = true
+	}
+
+	// Numeric zero values must carry their type, as an untyped 0 would
+	// be treated as an int when assigned to an interface{}
+	zeroVal := fmt.Sprintf("%#v", reflect.Zero(rv))
+	switch rv.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		zeroVal = typ + `(0)`
+	case reflect.Array:
+		zeroVal = typ + `{}`
+	}
+
+	// Byte arrays are encoded as strings in JSON, instead of
+	// arrays of numbers
+	var jsonEncoding string
+	if isArray && rv.Elem().Kind() == reflect.Uint8 {
+		jsonEncoding = JSONEncodingBase64
+	}
+
 	return &TypeSpec{
 		name:                  typ,
 		apparentType:          typeName(apparentType),
@@ -343,9 +960,76 @@ func Type(v interface{}) *TypeSpec {
 		getValueMethodName:    getValueMethodName,
 		initArgStyle:          initArgStyle,
 		supportsLen:           supportsLen,
-		zeroVal:               fmt.Sprintf("%#v", reflect.Zero(rv)),
+		supportsConst:         supportsConst,
+		zeroVal:               zeroVal,
+		jsonEncoding:          jsonEncoding,
+		textMarshaler:         isTextMarshaler(apparentType),
+		sqlScannable:          isSQLScannable(rawRV),
+		inferredGraphQLType:   graphQLTypeOf(apparentType),
+		inferredJSONSchema:    jsonSchemaOf(apparentType),
 		isInterface:           isInterface,
+		isSlice:               isSlice,
+		isArray:               isArray,
+		isMap:                 isMap,
+		isComparable:          rv.Comparable(),
+		imports:               importPaths(rv, apparentType),
+		mapKey:                mapKey,
+		mapElement:            mapElement,
+	}
+}
+
+// importPaths returns the sorted import paths of the packages that
+// declare the named types referred to by the given types, including
+// the elements of pointers, slices, arrays, and maps
+func importPaths(types ...reflect.Type) []string {
+	seen := make(map[string]struct{})
+	var walk func(reflect.Type)
+	walk = func(rv reflect.Type) {
+		if path := rv.PkgPath(); path != "" {
+			seen[path] = struct{}{}
+			return
+		}
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			walk(rv.Elem())
+		case reflect.Map:
+			walk(rv.Key())
+			walk(rv.Elem())
+		}
 	}
+	for _, rv := range types {
+		walk(rv)
+	}
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+var typSQLScanner = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var typSQLValuer = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// isSQLScannable returns true if the pointer type of rv implements
+// both sql.Scanner and driver.Valuer
+func isSQLScannable(rv reflect.Type) bool {
+	if rv.Kind() == reflect.Interface {
+		return false
+	}
+	ptr := reflect.PtrTo(rv)
+	return ptr.Implements(typSQLScanner) && ptr.Implements(typSQLValuer)
+}
+
+var typTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var typTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isTextMarshaler returns true if values of rv can be converted to and
+// from text, i.e. rv implements encoding.TextMarshaler, and its pointer
+// type implements encoding.TextUnmarshaler
+func isTextMarshaler(rv reflect.Type) bool {
+	return rv.Implements(typTextMarshaler) && reflect.PtrTo(rv).Implements(typTextUnmarshaler)
 }
 
 // TypeName creates a TypeSpec from a string name.
@@ -363,15 +1047,28 @@ func Type(v interface{}) *TypeSpec {
 // The defualt zero value is assumed to be `nil`
 //
 // If the name starts with a `[]`, then `IsSlice()` is automatically set to true
-// If the name starts with a `map[`, then `IsMap()` is automatically set to true
+// If the name starts with a `map[`, then `IsMap()` is automatically set to true,
+// and the key and element types are populated from the name
+//
+// If the name is a generic type with a single type argument (e.g. `Set[string]`),
+// the type argument is used as the element type
 func TypeName(name string) *TypeSpec {
 	isSlice := strings.HasPrefix(name, `[]`)
 	isMap := strings.HasPrefix(name, `map[`)
 	element := "sketch.UnknownType" // so it's easier to see
 	var initArgStyle InitializerArgumentStyle
+	var mapKey, mapElement string
 	if isSlice {
 		initArgStyle = InitializerArgumentAsSlice
 		element = strings.TrimPrefix(name, `[]`)
+	} else if isMap {
+		if k, e, ok := splitMapTypeName(name); ok {
+			mapKey = k
+			mapElement = e
+			element = e
+		}
+	} else if _, args, ok := splitTypeArgs(strings.TrimPrefix(name, `*`)); ok && len(args) == 1 {
+		element = args[0]
 	}
 
 	var supportsLen bool
@@ -400,7 +1097,72 @@ func TypeName(name string) *TypeSpec {
 		initArgStyle: initArgStyle,
 		supportsLen:  supportsLen,
 		zeroVal:      `nil`,
+		isSlice:      isSlice,
+		isMap:        isMap,
+		isComparable: !isSlice && !isMap,
+		mapKey:       mapKey,
+		mapElement:   mapElement,
+	}
+}
+
+// splitMapTypeName splits a type name such as `map[string][]int` into
+// its key and element types
+func splitMapTypeName(name string) (string, string, bool) {
+	rest := strings.TrimPrefix(name, `map[`)
+	var depth int
+	for i, c := range rest {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				if i+1 >= len(rest) {
+					return "", "", false
+				}
+				return rest[:i], rest[i+1:], true
+			}
+			depth--
+		}
+	}
+	return "", "", false
+}
+
+// splitTypeArgs splits the name of an instantiated generic type such as
+// `Pair[string, []int]` into the name of the generic type and its
+// type arguments
+func splitTypeArgs(name string) (string, []string, bool) {
+	i := strings.IndexByte(name, '[')
+	if i <= 0 || !strings.HasSuffix(name, `]`) || strings.HasPrefix(name, `map[`) {
+		return "", nil, false
+	}
+
+	var args []string
+	var depth int
+	start := i + 1
+	inner := name[:len(name)-1]
+	for j := start; j < len(inner); j++ {
+		switch inner[j] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(inner[start:j]))
+				start = j + 1
+			}
+		}
 	}
+	if depth != 0 {
+		return "", nil, false
+	}
+	args = append(args, strings.TrimSpace(inner[start:]))
+	for _, arg := range args {
+		if arg == "" {
+			return "", nil, false
+		}
+	}
+	return name[:i], args, true
 }
 
 func (ts *TypeSpec) InitializerArgumentStyle(ias InitializerArgumentStyle) *TypeSpec {
@@ -425,6 +1187,73 @@ func (ts *TypeSpec) GetIsInterface() bool {
 	return ts.isInterface
 }
 
+// IsSlice should be set to true if the storage type is a slice.
+func (ts *TypeSpec) IsSlice(b bool) *TypeSpec {
+	ts.isSlice = b
+	return ts
+}
+
+func (ts *TypeSpec) GetIsSlice() bool {
+	return ts.isSlice
+}
+
+// IsArray should be set to true if the storage type is a fixed-size array.
+func (ts *TypeSpec) IsArray(b bool) *TypeSpec {
+	ts.isArray = b
+	return ts
+}
+
+func (ts *TypeSpec) GetIsArray() bool {
+	return ts.isArray
+}
+
+// IsMap should be set to true if the storage type is a map.
+func (ts *TypeSpec) IsMap(b bool) *TypeSpec {
+	ts.isMap = b
+	return ts
+}
+
+func (ts *TypeSpec) GetIsMap() bool {
+	return ts.isMap
+}
+
+// IsComparable should be set to true if values of the storage type
+// can be compared using the `==` operator. When this is false,
+// `reflect.DeepEqual` is used to compare values instead.
+func (ts *TypeSpec) IsComparable(b bool) *TypeSpec {
+	ts.isComparable = b
+	return ts
+}
+
+func (ts *TypeSpec) GetIsComparable() bool {
+	return ts.isComparable
+}
+
+// GetIsNumeric returns true if the apparent type is one of Go's
+// built-in numeric types
+func (ts *TypeSpec) GetIsNumeric() bool {
+	switch ts.GetApparentType() {
+	case `int`, `int8`, `int16`, `int32`, `int64`,
+		`uint`, `uint8`, `uint16`, `uint32`, `uint64`,
+		`float32`, `float64`:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetIsInteger returns true if the apparent type is one of Go's
+// built-in integer types
+func (ts *TypeSpec) GetIsInteger() bool {
+	switch ts.GetApparentType() {
+	case `int`, `int8`, `int16`, `int32`, `int64`,
+		`uint`, `uint8`, `uint16`, `uint32`, `uint64`:
+		return true
+	default:
+		return false
+	}
+}
+
 // InterfaceDecoder should be set to the name of the function that
 // can take a `[]byte` variable and return a value assignable to
 // the type. For example a type specified as below
@@ -438,7 +1267,8 @@ func (ts *TypeSpec) GetIsInterface() bool {
 //	val, err = mypkg.Parse(src) // src is []byte
 //
 // This value is not automatically assigned. Therefore you will
-// laways need to specify this if you are referring to an interface
+// laways need to specify this if you are referring to an interface.
+// The package containing the function should be declared via `Imports`
 func (ts *TypeSpec) InterfaceDecoder(s string) *TypeSpec {
 	ts.interfaceDecoder = s
 	return ts
@@ -514,89 +1344,554 @@ func (ts *TypeSpec) AcceptValueMethodName(s string) *TypeSpec {
 	return ts
 }
 
-func (ts *TypeSpec) ApparentType(s string) *TypeSpec {
-	ts.apparentType = s
+// CloneMethodName sets the name of the method that creates a deep copy
+// of the storage type. The method must take no arguments and return
+// a value of the same type as the storage (i.e. the pointer type).
+//
+// This is used when generating deep copies of the object (see
+// `--with-clone`). If unspecified, custom storage types (those that
+// implement the `GetValue` or `AcceptValue` semantics) are copied
+// by pointer, and thus the copies share the same underlying value.
+func (ts *TypeSpec) CloneMethodName(s string) *TypeSpec {
+	ts.cloneMethodName = s
 	return ts
 }
 
-func (ts *TypeSpec) GetName() string {
-	return ts.name
+// GetCloneMethodName returns the name of the method used to
+// create deep copies of the storage type.
+func (ts *TypeSpec) GetCloneMethodName() string {
+	return ts.cloneMethodName
 }
 
-// GetGetValueMethodName returns the name of the `GetValue` method.
-func (ts *TypeSpec) GetGetValueMethodName() string {
-	return ts.getValueMethodName
+// EqualMethodName sets the name of the method that compares two values
+// of the storage type. The method must take a single argument of the
+// same type as the storage (i.e. the pointer type), and return a bool.
+//
+// This is used when generating the `Equal` method (see `--with-equal`).
+// If unspecified, values are compared using `==` when the type is
+// comparable, or `reflect.DeepEqual` otherwise.
+func (ts *TypeSpec) EqualMethodName(s string) *TypeSpec {
+	ts.equalMethodName = s
+	return ts
 }
 
-// GetAcceptValueMethodName returns the name of the `AcceptValue` method.
-func (ts *TypeSpec) GetAcceptValueMethodName() string {
-	return ts.acceptValueMethodName
+// GetEqualMethodName returns the name of the method used to
+// compare two values of the storage type.
+func (ts *TypeSpec) GetEqualMethodName() string {
+	return ts.equalMethodName
 }
 
-func (ts *TypeSpec) GetZeroVal() string {
-	return ts.zeroVal
+// ResetMethodName sets the name of the method that releases the
+// resources held by a value of the storage type. The method must
+// take no arguments, and its return values (if any) are ignored.
+//
+// This is used when generating the `Reset` method (see `--with-reset`).
+// The method is called on the stored value before the field is
+// unset. If unspecified, the field is simply unset.
+func (ts *TypeSpec) ResetMethodName(s string) *TypeSpec {
+	ts.resetMethodName = s
+	return ts
 }
 
-func (ts *TypeSpec) GetApparentType() string {
-	typ := ts.apparentType
-	if typ == "" {
-		typ = ts.name
-	}
-	return typ
+// GetResetMethodName returns the name of the method used to
+// reset values of the storage type.
+func (ts *TypeSpec) GetResetMethodName() string {
+	return ts.resetMethodName
 }
 
-func (ts *TypeSpec) GetElement() string {
-	return ts.element
+// SQLType sets the SQL column type (e.g. "TEXT", "BLOB") that fields
+// of this type are stored as. When specified along with `--with-sql`,
+// accessors to read and write individual fields from and to
+// `database/sql` are generated for fields of this type, unless the
+// type is scannable (see `SQLScannable`).
+func (ts *TypeSpec) SQLType(s string) *TypeSpec {
+	ts.sqlType = s
+	return ts
 }
 
-func (ts *TypeSpec) GetSupportsLen() bool {
-	return ts.supportsLen
+// GetSQLType returns the SQL column type for this type.
+func (ts *TypeSpec) GetSQLType() string {
+	return ts.sqlType
 }
 
-func (ts *TypeSpec) SupportsLen(b bool) *TypeSpec {
-	ts.supportsLen = b
+// SQLScannable specifies that the storage type implements sql.Scanner
+// (via its pointer type) and driver.Valuer, so that fields of this type
+// can be mapped directly to a single SQL column. For such fields,
+// `--with-sql` generates the `ScanXXX` and `XXXSQLValue` methods, which
+// delegate to the `Scan` and `Value` methods of the storage type. This is detected
+// automatically for types created via `Type`.
+//
+// If the storage type does not actually implement these interfaces,
+// the generated code fails to compile, reporting that the type does
+// not implement the `Scan` or `Value` method.
+func (ts *TypeSpec) SQLScannable(b bool) *TypeSpec {
+	ts.sqlScannable = b
 	return ts
 }
 
-// PointerType specifies the "indirect" type of a field. The fields
-// are stored as _pointers_ to the actual type, so for most types
-// we simply prepend a `*` to the type. For example for a `string`
-// type, the indirect type would be `*string`, whereas for `*Foo`
-// type, we just use `*Foo` as the indirect type. But for cases when
-// you would like to store an interface, for example, you might
-// want to avoid prepending the `*` by explicitly specifying the
-// name of the indirect type.
-func (ts *TypeSpec) PointerType(s string) *TypeSpec {
-	ts.ptrType = s
-	return ts
+// GetSQLScannable returns true if the storage type implements
+// sql.Scanner and driver.Valuer.
+func (ts *TypeSpec) GetSQLScannable() bool {
+	return ts.sqlScannable
 }
 
-func (ts *TypeSpec) RawType(s string) *TypeSpec {
-	ts.rawType = s
+// GraphQLType sets the name of the GraphQL type (e.g. "DateTime") that
+// fields of this type are represented as when generating GraphQL type
+// definitions (see `--with-graphql`).
+func (ts *TypeSpec) GraphQLType(s string) *TypeSpec {
+	ts.graphQLType = s
 	return ts
 }
 
-func (ts *TypeSpec) Element(s string) *TypeSpec {
-	ts.element = s
+// GetGraphQLType returns the name of the GraphQL type for this type.
+// Unless specified via `GraphQLType`, it is derived from the apparent type:
+// strings and byte slices map to `String`, booleans to `Boolean`, integers
+// to `Int`, floating point numbers to `Float`, and slices of these types
+// to lists (e.g. `[String]`). The empty string is returned if the GraphQL
+// type cannot be determined.
+func (ts *TypeSpec) GetGraphQLType() string {
+	if ts.graphQLType != "" {
+		return ts.graphQLType
+	}
+	if typ := graphQLTypeName(ts.GetApparentType()); typ != "" {
+		return typ
+	}
+	// named types (e.g. `type Names []string`) are resolved using
+	// the kind of the type that was given to `Type`
+	return ts.inferredGraphQLType
+}
+
+// graphQLTypeOf returns the GraphQL type corresponding to rv, or an
+// empty string if there is none
+func graphQLTypeOf(rv reflect.Type) string {
+	switch rv.Kind() {
+	case reflect.String:
+		return `String`
+	case reflect.Bool:
+		return `Boolean`
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return `Int`
+	case reflect.Float32, reflect.Float64:
+		return `Float`
+	case reflect.Slice:
+		if rv.Elem().Kind() == reflect.Uint8 {
+			return `String`
+		}
+		if elem := graphQLTypeOf(rv.Elem()); elem != "" {
+			return `[` + elem + `]`
+		}
+	}
+	return ""
+}
+
+// graphQLTypeName is the same as graphQLTypeOf, but works on
+// type names
+func graphQLTypeName(name string) string {
+	switch name {
+	case `string`, `[]byte`:
+		return `String`
+	case `bool`:
+		return `Boolean`
+	case `int`, `int8`, `int16`, `int32`, `int64`,
+		`uint`, `uint8`, `uint16`, `uint32`, `uint64`:
+		return `Int`
+	case `float32`, `float64`:
+		return `Float`
+	}
+
+	if elem := strings.TrimPrefix(name, `[]`); elem != name {
+		if typ := graphQLTypeName(elem); typ != "" {
+			return `[` + typ + `]`
+		}
+	}
+	return ""
+}
+
+// TextMarshaler specifies that the apparent type implements the
+// encoding.TextMarshaler interface, and its pointer type implements
+// the encoding.TextUnmarshaler interface, so that values can be
+// converted to and from strings (e.g. when generating `EncodeForm`).
+// This is detected automatically for types created via `Type`, unless
+// the apparent type is overridden via `ApparentType`.
+func (ts *TypeSpec) TextMarshaler(b bool) *TypeSpec {
+	ts.textMarshaler = b
 	return ts
 }
 
-func (ts *TypeSpec) SliceStyleInitializerArgument() bool {
-	return ts.initArgStyle == InitializerArgumentAsSlice
+// GetTextMarshaler returns true if the apparent type can be converted
+// to and from strings via encoding.TextMarshaler and
+// encoding.TextUnmarshaler.
+func (ts *TypeSpec) GetTextMarshaler() bool {
+	return ts.textMarshaler
 }
 
-// FieldSpec represents a field that belongs to a particular schema.
-type FieldSpec struct {
-	required       bool
+// JSONSchemaType sets the JSON Schema type (e.g. "string") that values
+// of this type are represented as in JSON. This is used when generating
+// JSON Schema documents (see `--with-jsonschema`).
+func (ts *TypeSpec) JSONSchemaType(s string) *TypeSpec {
+	ts.jsonSchemaType = s
+	return ts
+}
+
+// OpenAPIType sets the type that values of this type are described as
+// in OpenAPI component schemas (see `--with-openapi`). Unless specified,
+// the type in the JSON Schema returned by `GetJSONSchema` is used.
+func (ts *TypeSpec) OpenAPIType(s string) *TypeSpec {
+	ts.openAPIType = s
+	return ts
+}
+
+// GetOpenAPIType returns the OpenAPI type specified via `OpenAPIType`
+func (ts *TypeSpec) GetOpenAPIType() string {
+	return ts.openAPIType
+}
+
+// OpenAPIFormat sets the format (e.g. "uuid") that values of this type
+// are described with in OpenAPI component schemas (see `--with-openapi`).
+// Unless specified, the format is derived from the apparent type (see
+// `FieldSpec.GetOpenAPISchema`).
+func (ts *TypeSpec) OpenAPIFormat(s string) *TypeSpec {
+	ts.openAPIFormat = s
+	return ts
+}
+
+// GetOpenAPIFormat returns the OpenAPI format specified via `OpenAPIFormat`
+func (ts *TypeSpec) GetOpenAPIFormat() string {
+	return ts.openAPIFormat
+}
+
+// GetJSONSchema returns the JSON Schema describing values of this type.
+// Unless the type is specified via `JSONSchemaType`, it is derived from
+// the apparent type: strings and byte slices map to `string`, booleans to
+// `boolean`, integers to `integer`, floating point numbers to `number`,
+// slices to `array`, and maps with string keys to `object`. An empty
+// schema, which accepts any value, is returned if the type cannot be
+// determined.
+func (ts *TypeSpec) GetJSONSchema() map[string]interface{} {
+	if ts.jsonSchemaType != "" {
+		return map[string]interface{}{`type`: ts.jsonSchemaType}
+	}
+	if s := jsonSchemaName(ts.GetApparentType()); s != nil {
+		return s
+	}
+
+	// named types (e.g. `type Names []string`) are resolved using
+	// the kind of the type that was given to `Type`
+	s := make(map[string]interface{}, len(ts.inferredJSONSchema))
+	for k, v := range ts.inferredJSONSchema {
+		s[k] = v
+	}
+	return s
+}
+
+// jsonSchemaOf returns the JSON Schema corresponding to rv, or nil if
+// there is none
+func jsonSchemaOf(rv reflect.Type) map[string]interface{} {
+	switch rv.Kind() {
+	case reflect.String:
+		return map[string]interface{}{`type`: `string`}
+	case reflect.Bool:
+		return map[string]interface{}{`type`: `boolean`}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{`type`: `integer`}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{`type`: `number`}
+	case reflect.Slice, reflect.Array:
+		if rv.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{`type`: `string`}
+		}
+		s := map[string]interface{}{`type`: `array`}
+		if items := jsonSchemaOf(rv.Elem()); items != nil {
+			s[`items`] = items
+		}
+		return s
+	case reflect.Map:
+		if rv.Key().Kind() != reflect.String {
+			return nil
+		}
+		s := map[string]interface{}{`type`: `object`}
+		if values := jsonSchemaOf(rv.Elem()); values != nil {
+			s[`additionalProperties`] = values
+		}
+		return s
+	}
+	return nil
+}
+
+// jsonSchemaName is the same as jsonSchemaOf, but works on type names
+func jsonSchemaName(name string) map[string]interface{} {
+	switch name {
+	case `string`, `[]byte`:
+		return map[string]interface{}{`type`: `string`}
+	case `bool`:
+		return map[string]interface{}{`type`: `boolean`}
+	case `int`, `int8`, `int16`, `int32`, `int64`,
+		`uint`, `uint8`, `uint16`, `uint32`, `uint64`:
+		return map[string]interface{}{`type`: `integer`}
+	case `float32`, `float64`:
+		return map[string]interface{}{`type`: `number`}
+	}
+
+	if elem := strings.TrimPrefix(name, `[]`); elem != name {
+		s := map[string]interface{}{`type`: `array`}
+		if items := jsonSchemaName(elem); items != nil {
+			s[`items`] = items
+		}
+		return s
+	}
+	if key, elem, ok := splitMapTypeName(name); ok && key == `string` {
+		s := map[string]interface{}{`type`: `object`}
+		if values := jsonSchemaName(elem); values != nil {
+			s[`additionalProperties`] = values
+		}
+		return s
+	}
+	return nil
+}
+
+// Encodings that can be specified via TypeSpec.JSONEncoding
+const (
+	JSONEncodingBase64 = `base64`
+	JSONEncodingHex    = `hex`
+	JSONEncodingArray  = `array`
+)
+
+// JSONEncoding specifies how values of this type are represented in
+// JSON. This can be specified for byte slices and byte arrays (e.g.
+// `[16]byte`) to encode them as base64 strings (JSONEncodingBase64),
+// hex strings (JSONEncodingHex), or arrays of numbers (JSONEncodingArray).
+//
+// Byte arrays are encoded as base64 strings by default. Byte slices
+// are left to "encoding/json", which also encodes them as base64 strings.
+// When decoding into a byte array, the length of the decoded value must
+// match the length of the array, otherwise an error is returned.
+//
+// Note that `ByteSliceType` and `NativeByteSliceType` are shared between
+// all fields, and therefore should not be modified. Create a new type
+// instead, e.g. `schema.Type([]byte(nil)).JSONEncoding(schema.JSONEncodingHex)`.
+func (ts *TypeSpec) JSONEncoding(s string) *TypeSpec {
+	ts.jsonEncoding = s
+	return ts
+}
+
+// GetJSONEncoding returns the JSON encoding for this type. An empty
+// string means that the value is encoded using "encoding/json" as is.
+func (ts *TypeSpec) GetJSONEncoding() string {
+	return ts.jsonEncoding
+}
+
+// EmptyStringAsNull specifies that an empty JSON string is treated the
+// same way as a JSON null when decoding, leaving the field unset. This
+// only applies to types that specify an `AcceptValue` method.
+func (ts *TypeSpec) EmptyStringAsNull(b bool) *TypeSpec {
+	ts.emptyStringAsNull = b
+	return ts
+}
+
+// GetEmptyStringAsNull returns true if an empty JSON string should leave
+// the field unset when decoding
+func (ts *TypeSpec) GetEmptyStringAsNull() bool {
+	return ts.emptyStringAsNull
+}
+
+func (ts *TypeSpec) ApparentType(s string) *TypeSpec {
+	ts.apparentType = s
+	// whether the type implements encoding.TextMarshaler was detected
+	// for the previous apparent type
+	ts.textMarshaler = false
+	return ts
+}
+
+func (ts *TypeSpec) GetName() string {
+	return ts.name
+}
+
+// GetGetValueMethodName returns the name of the `GetValue` method.
+func (ts *TypeSpec) GetGetValueMethodName() string {
+	return ts.getValueMethodName
+}
+
+// GetAcceptValueMethodName returns the name of the `AcceptValue` method.
+func (ts *TypeSpec) GetAcceptValueMethodName() string {
+	return ts.acceptValueMethodName
+}
+
+// Imports adds the import paths of the packages that the generated code
+// refers to when using this type, such as the package containing a type
+// declared via `TypeName`, or the function given to `InterfaceDecoder`.
+//
+//	schema.TypeName(`mypkg.Thing`).
+//	  InterfaceDecoder(`mypkg.Parse`).
+//	  Imports(`example.com/mymodule/mypkg`)
+//
+// The packages are added to the imports of the files generated for the
+// objects that contain fields of this type, and are removed from the
+// files that do not reference them.
+func (ts *TypeSpec) Imports(paths ...string) *TypeSpec {
+	seen := make(map[string]struct{})
+	list := make([]string, 0, len(ts.imports)+len(paths))
+	for _, path := range append(ts.imports, paths...) {
+		if _, ok := seen[path]; ok || path == "" {
+			continue
+		}
+		seen[path] = struct{}{}
+		list = append(list, path)
+	}
+	sort.Strings(list)
+	ts.imports = list
+	return ts
+}
+
+// GetImports returns the import paths of the packages that the generated
+// code refers to when using this type. For types created via `Type`, these
+// include the packages that declare the storage type and the apparent type
+// (or their elements), along with those specified via `Imports`.
+func (ts *TypeSpec) GetImports() []string {
+	return ts.imports
+}
+
+func (ts *TypeSpec) GetZeroVal() string {
+	return ts.zeroVal
+}
+
+func (ts *TypeSpec) GetApparentType() string {
+	typ := ts.apparentType
+	if typ == "" {
+		if ts.mapKey != "" && ts.mapElement != "" {
+			return `map[` + ts.mapKey + `]` + ts.mapElement
+		}
+		typ = ts.name
+	}
+	return typ
+}
+
+// MapKey sets the key type of the map that the user sees. When both
+// `MapKey` and `MapElement` are specified and no apparent type has
+// been specified via `ApparentType`, the apparent type becomes
+// `map[K]V`. This allows custom storage types to be exposed as maps.
+func (ts *TypeSpec) MapKey(s string) *TypeSpec {
+	ts.mapKey = s
+	return ts
+}
+
+// GetMapKey returns the key type of the map, if the type is a map.
+func (ts *TypeSpec) GetMapKey() string {
+	return ts.mapKey
+}
+
+// MapElement sets the element type of the map that the user sees.
+// See `MapKey` for details.
+func (ts *TypeSpec) MapElement(s string) *TypeSpec {
+	ts.mapElement = s
+	ts.element = s
+	return ts
+}
+
+// GetMapElement returns the element type of the map, if the type is a map.
+func (ts *TypeSpec) GetMapElement() string {
+	return ts.mapElement
+}
+
+func (ts *TypeSpec) GetElement() string {
+	return ts.element
+}
+
+func (ts *TypeSpec) GetSupportsLen() bool {
+	return ts.supportsLen
+}
+
+func (ts *TypeSpec) SupportsLen(b bool) *TypeSpec {
+	ts.supportsLen = b
+	return ts
+}
+
+// SupportsConst specifies if values of the apparent type can be declared
+// as Go constants. This is automatically set for types created via `Type`
+// whose apparent type is a boolean, string, or numeric type, and is used
+// to declare the values of constant fields (see `FieldSpec.ConstantValue`).
+func (ts *TypeSpec) SupportsConst(b bool) *TypeSpec {
+	ts.supportsConst = b
+	return ts
+}
+
+// GetSupportsConst returns true if values of the apparent type can be
+// declared as Go constants.
+func (ts *TypeSpec) GetSupportsConst() bool {
+	return ts.supportsConst
+}
+
+// PointerType specifies the "indirect" type of a field. The fields
+// are stored as _pointers_ to the actual type, so for most types
+// we simply prepend a `*` to the type. For example for a `string`
+// type, the indirect type would be `*string`, whereas for `*Foo`
+// type, we just use `*Foo` as the indirect type. But for cases when
+// you would like to store an interface, for example, you might
+// want to avoid prepending the `*` by explicitly specifying the
+// name of the indirect type.
+func (ts *TypeSpec) PointerType(s string) *TypeSpec {
+	ts.ptrType = s
+	return ts
+}
+
+func (ts *TypeSpec) RawType(s string) *TypeSpec {
+	ts.rawType = s
+	return ts
+}
+
+func (ts *TypeSpec) Element(s string) *TypeSpec {
+	ts.element = s
+	return ts
+}
+
+func (ts *TypeSpec) SliceStyleInitializerArgument() bool {
+	return ts.initArgStyle == InitializerArgumentAsSlice
+}
+
+// FieldSpec represents a field that belongs to a particular schema.
+type FieldSpec struct {
+	required       bool
 	name           string
 	typ            *TypeSpec
 	typName        string
 	unexportedName string
 	json           string
+	omitEmpty      *bool
+	yaml           string
+	xml            string
+	xmlAttr        bool
+	cborKey        *int
+	form           string
+	toml           string
 	comment        string
 	extension      bool
 	extra          map[string]interface{}
 	constant       *string
+	hasDefault     bool
+	defaultValue   interface{}
+	hasExample     bool
+	example        interface{}
+	minLen         *int
+	maxLen         *int
+	pattern        string
+	min            *float64
+	max            *float64
+	hasMethod      *bool
+	secret         bool
+	readOnly       bool
+	writeOnly      bool
+	deprecated     *string
+	jsonAliases    []string
+	jsonString     bool
+	marshalJSON    string
+	unmarshalJSON  string
+	variadicAdder  bool
+	embedded       string
+	embeddedObject Interface
+	tags           map[string]string
+	enum           []interface{}
+	enumTypeName   string
 }
 
 var typInfoType = reflect.TypeOf((*TypeSpec)(nil))
@@ -624,6 +1919,55 @@ func Field(name string, typ interface{}) *FieldSpec {
 	return f
 }
 
+// Embed returns copies of the fields declared in the given object, so that
+// they can be promoted into another object. The promoted fields behave
+// exactly as if they had been declared in the embedding object: they
+// become fields of the generated struct, and their JSON keys are
+// encoded and decoded at the top level.
+//
+//	func (Object) Fields() []*schema.FieldSpec {
+//	  return append([]*schema.FieldSpec{
+//	    schema.String(`Name`),
+//	  }, schema.Embed(Metadata{})...)
+//	}
+//
+// Promoted fields do not declare their own key name constants. Instead
+// they refer to the constants declared by the embedded object, which
+// therefore must also be generated in the same package.
+//
+// Modifying the returned fields does not affect the embedded object.
+func Embed(object Interface) []*FieldSpec {
+	// Same rules as the sketch compiler: use the struct name unless
+	// the object provides its own name
+	name := object.Name()
+	if name == "" {
+		name = indirectType(object).Name()
+	}
+
+	fields := object.Fields()
+	promoted := make([]*FieldSpec, len(fields))
+	for i, f := range fields {
+		clone := *f
+		clone.embedded = name
+		clone.embeddedObject = object
+		clone.extra = make(map[string]interface{}, len(f.extra))
+		for k, v := range f.extra {
+			clone.extra[k] = v
+		}
+		promoted[i] = &clone
+	}
+	return promoted
+}
+
+// indirectType returns the type of v, dereferencing any pointers
+func indirectType(v interface{}) reflect.Type {
+	rt := reflect.TypeOf(v)
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	return rt
+}
+
 func (f *FieldSpec) Extra(name string, value interface{}) *FieldSpec {
 	f.extra[name] = value
 	return f
@@ -652,6 +1996,36 @@ func Int(name string) *FieldSpec {
 	return Field(name, int(0))
 }
 
+// Int32 creates a new field with the given name and a int32 type
+func Int32(name string) *FieldSpec {
+	return Field(name, int32(0))
+}
+
+// Int64 creates a new field with the given name and a int64 type
+func Int64(name string) *FieldSpec {
+	return Field(name, int64(0))
+}
+
+// Uint creates a new field with the given name and a uint type
+func Uint(name string) *FieldSpec {
+	return Field(name, uint(0))
+}
+
+// Uint64 creates a new field with the given name and a uint64 type
+func Uint64(name string) *FieldSpec {
+	return Field(name, uint64(0))
+}
+
+// Float32 creates a new field with the given name and a float32 type
+func Float32(name string) *FieldSpec {
+	return Field(name, float32(0))
+}
+
+// Float64 creates a new field with the given name and a float64 type
+func Float64(name string) *FieldSpec {
+	return Field(name, float64(0))
+}
+
 // Bool creates a new field with the given name and a bool type
 func Bool(name string) *FieldSpec {
 	return Field(name, true)
@@ -679,12 +2053,142 @@ func ByteSlice(name string) *FieldSpec {
 	return Field(name, ByteSliceType)
 }
 
+// RawJSONType represents a `json.RawMessage` type. The value is not
+// interpreted: the bytes are captured as is when decoding, and written
+// back as is when encoding, except that encoding/json removes
+// insignificant whitespace.
+//
+// The generated code uses "encoding/json", which is imported
+// automatically.
+//
+// The type is declared by name, as `json.RawMessage` may be an alias of
+// a type in another package depending on the version of Go.
+var RawJSONType = TypeName(`json.RawMessage`).
+	Imports(`encoding/json`).
+	PointerType(`json.RawMessage`).
+	IsSlice(true).
+	IsComparable(false).
+	SupportsLen(true).
+	Element(`byte`).
+	ZeroVal(`json.RawMessage(nil)`)
+
+// RawJSON creates a new field with the given name and a json.RawMessage
+// type. The field is omitted from the JSON representation when it is
+// empty, as if `OmitEmpty(true)` has been specified.
+func RawJSON(name string) *FieldSpec {
+	return Field(name, RawJSONType).OmitEmpty(true)
+}
+
+// DurationType represents a `time.Duration` type, which is stored as a
+// `duration.Duration`. When encoded into JSON the value is serialized as
+// a Go duration string (e.g. "1h30m0s").
+//
+// When decoding, strings are parsed via `time.ParseDuration`, and numeric
+// values are treated as nanoseconds. A JSON null or an empty string is
+// accepted, and leaves the field unset.
+//
+// The generated code uses the "time" and "github.com/lestrrat-go/sketch/duration"
+// packages, which are imported automatically.
+var DurationType = Type(duration.Duration{}).
+	Imports(`time`).
+	ApparentType(`time.Duration`).
+	AcceptValue(true).
+	GetValue(true).
+	CloneMethodName(`Clone`).
+	EqualMethodName(`Equal`).
+	EmptyStringAsNull(true).
+	GraphQLType(`String`).
+	JSONSchemaType(`string`).
+	ZeroVal(`time.Duration(0)`)
+
+// Duration creates a new field with the given name and a time.Duration type
+func Duration(name string) *FieldSpec {
+	return Field(name, DurationType)
+}
+
+// UUIDType represents a `uuid.UUID` type from "github.com/google/uuid",
+// which is stored as a `uuidvalue.UUID`. When encoded into JSON the value
+// is serialized as the canonical hyphenated string.
+//
+// When decoding, strings are parsed via `uuid.Parse`, and invalid strings
+// are reported as errors that contain the name of the field. A JSON null
+// is accepted, and leaves the field unset.
+//
+// The type is declared by name, so that this package does not depend on
+// "github.com/google/uuid". The generated code uses the "github.com/google/uuid"
+// and "github.com/lestrrat-go/sketch/uuidvalue" packages, which are imported
+// automatically, but must be added to the module that contains the generated
+// code.
+var UUIDType = TypeName(`uuidvalue.UUID`).
+	Imports(`github.com/google/uuid`, `github.com/lestrrat-go/sketch/uuidvalue`).
+	ApparentType(`uuid.UUID`).
+	AcceptValue(true).
+	GetValue(true).
+	CloneMethodName(`Clone`).
+	EqualMethodName(`Equal`).
+	TextMarshaler(true).
+	GraphQLType(`ID`).
+	JSONSchemaType(`string`).
+	OpenAPIFormat(`uuid`).
+	ZeroVal(`uuid.Nil`)
+
+// UUID creates a new field with the given name and a uuid.UUID type
+func UUID(name string) *FieldSpec {
+	return Field(name, UUIDType)
+}
+
+// TimeType represents a `time.Time` type, which is stored as an
+// `epoch.Time`. When encoded into JSON the value is serialized as the
+// number of seconds since the Unix epoch.
+//
+// When decoding, numeric values are treated as seconds since the Unix
+// epoch, and strings are parsed as RFC3339 timestamps. A JSON null is
+// accepted, and leaves the field unset.
+//
+// The generated code uses the "time" and "github.com/lestrrat-go/sketch/epoch"
+// packages, which are imported automatically.
+var TimeType = Type(epoch.Time{}).
+	Imports(`time`).
+	ApparentType(`time.Time`).
+	AcceptValue(true).
+	GetValue(true).
+	CloneMethodName(`Clone`).
+	EqualMethodName(`Equal`).
+	TextMarshaler(true).
+	GraphQLType(`Int`).
+	JSONSchemaType(`integer`).
+	ZeroVal(`time.Time{}`)
+
+// Time creates a new field with the given name and a time.Time type
+func Time(name string) *FieldSpec {
+	return Field(name, TimeType)
+}
+
 func (f *FieldSpec) GetName() string {
 	return f.name
 }
 
+// GetType returns the type of the field. For fields declared via `Enum`,
+// the type is the named type generated for the field, whose underlying
+// type is returned by `GetEnumBaseType`.
 func (f *FieldSpec) GetType() *TypeSpec {
-	return f.typ
+	if f.enum == nil || f.enumTypeName == "" {
+		return f.typ
+	}
+
+	enumType := *f.typ
+	enumType.name = f.enumTypeName
+	enumType.rawType = f.enumTypeName
+	enumType.apparentType = f.enumTypeName
+	enumType.ptrType = `*` + f.enumTypeName
+	enumType.zeroVal = f.enumTypeName + `(` + f.typ.GetZeroVal() + `)`
+	enumType.acceptValueMethodName = ""
+	enumType.getValueMethodName = ""
+	enumType.isComparable = true
+	enumType.supportsConst = true
+	enumType.graphQLType = f.typ.GetGraphQLType()
+	enumType.inferredJSONSchema = f.typ.GetJSONSchema()
+	return &enumType
 }
 
 // Unexported specifies the unexported name for this field.
@@ -703,9 +2207,319 @@ func (f *FieldSpec) JSON(s string) *FieldSpec {
 	return f
 }
 
+// JSONAliases specifies alternate JSON field names that are accepted
+// when decoding from JSON, in addition to the name returned by `GetJSON`.
+// Encoding always uses the name returned by `GetJSON`.
+//
+// If more than one of these names appear in the same JSON object,
+// the value that appears last is used.
+func (f *FieldSpec) JSONAliases(names ...string) *FieldSpec {
+	f.jsonAliases = append(f.jsonAliases, names...)
+	return f
+}
+
+// GetJSONAliases returns the list of alternate JSON field names
+// specified via `JSONAliases`
+func (f *FieldSpec) GetJSONAliases() []string {
+	return f.jsonAliases
+}
+
+// Tag specifies a struct tag to be emitted on the field in the generated
+// struct when struct tags are enabled (see `Base.StructTags`). Specifying
+// the "json" key replaces the tag that is generated from the JSON field
+// name.
+func (f *FieldSpec) Tag(key, value string) *FieldSpec {
+	if f.tags == nil {
+		f.tags = make(map[string]string)
+	}
+	f.tags[key] = value
+	return f
+}
+
+// GetTags returns the struct tags specified via `Tag`
+func (f *FieldSpec) GetTags() map[string]string {
+	return f.tags
+}
+
+// GetStructTag returns the struct tag for the field in the generated
+// struct as a Go string literal. The "json" tag comes first, followed
+// by the rest of the tags sorted by their keys.
+func (f *FieldSpec) GetStructTag() string {
+	jsonTag, ok := f.tags[`json`]
+	if !ok {
+		jsonTag = f.GetJSON()
+		if f.GetOmitEmpty() {
+			jsonTag += `,omitempty`
+		}
+	}
+
+	keys := make([]string, 0, len(f.tags))
+	for key := range f.tags {
+		if key != `json` {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(`json:`)
+	sb.WriteString(strconv.Quote(jsonTag))
+	for _, key := range keys {
+		sb.WriteByte(' ')
+		sb.WriteString(key)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Quote(f.tags[key]))
+	}
+
+	tag := sb.String()
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// JSONString specifies that the value of a numeric field should be
+// encoded as a JSON string (e.g. `"123"`), much like the `,string`
+// option in "encoding/json" struct tags. When decoding, both quoted
+// and unquoted numbers are accepted.
+func (f *FieldSpec) JSONString(b bool) *FieldSpec {
+	f.jsonString = b
+	return f
+}
+
+// GetJSONString returns true if the value of the field should be
+// encoded as a JSON string.
+func (f *FieldSpec) GetJSONString() bool {
+	return f.jsonString
+}
+
+// MarshalJSONFunc specifies the name of a function that encodes the
+// value of the field into JSON, for fields whose JSON representation
+// cannot be expressed otherwise (e.g. a `[]string` that is encoded as
+// a bare string when it only contains a single element). The function
+// must be of the form `func(T) ([]byte, error)`, where T is the apparent
+// type of the field.
+//
+// It must be specified along with `UnmarshalJSONFunc`. The functions
+// take precedence over `JSONString` and `TypeSpec.JSONEncoding`, and
+// are only used by the generated `MarshalJSON` and `UnmarshalJSON`
+// methods.
+func (f *FieldSpec) MarshalJSONFunc(name string) *FieldSpec {
+	f.marshalJSON = name
+	return f
+}
+
+// GetMarshalJSONFunc returns the name of the function that encodes
+// the value of the field into JSON.
+func (f *FieldSpec) GetMarshalJSONFunc() string {
+	return f.marshalJSON
+}
+
+// UnmarshalJSONFunc specifies the name of a function that decodes the
+// value of the field from JSON. The function must be of the form
+// `func([]byte) (T, error)`, where T is the apparent type of the field.
+// If the type of the field specifies an `AcceptValue` method, the
+// decoded value is passed to it.
+//
+// It must be specified along with `MarshalJSONFunc`.
+func (f *FieldSpec) UnmarshalJSONFunc(name string) *FieldSpec {
+	f.unmarshalJSON = name
+	return f
+}
+
+// GetUnmarshalJSONFunc returns the name of the function that decodes
+// the value of the field from JSON.
+func (f *FieldSpec) GetUnmarshalJSONFunc() string {
+	return f.unmarshalJSON
+}
+
+// VariadicAdder specifies that an `AddXXX` method that appends the
+// given values to the current value of the field should be generated
+// for the builder, in addition to the method that replaces the value.
+// This can only be specified for fields whose type accepts initializer
+// arguments as a slice (see `TypeSpec.SliceStyleInitializerArgument`).
+func (f *FieldSpec) VariadicAdder(b bool) *FieldSpec {
+	f.variadicAdder = b
+	return f
+}
+
+// GetVariadicAdder returns true if the `AddXXX` builder method should
+// be generated for this field.
+func (f *FieldSpec) GetVariadicAdder() bool {
+	return f.variadicAdder
+}
+
+// OmitEmpty specifies if the field should be omitted from the JSON
+// representation based on its value. By default fields are omitted
+// only when no value has been assigned to them, regardless of the value.
+//
+// Specifying `true` additionally omits fields that have been assigned
+// an empty value, following the convention of `omitempty` in
+// encoding/json: zero values, as well as empty strings, slices, and maps.
+// Specifying `false` forces the field to always be emitted, using
+// the zero value of the type when unset.
+func (f *FieldSpec) OmitEmpty(b bool) *FieldSpec {
+	f.omitEmpty = &b
+	return f
+}
+
+// GetOmitEmpty returns true if the field should be omitted from the
+// JSON representation when no value has been assigned to it.
+func (f *FieldSpec) GetOmitEmpty() bool {
+	return f.omitEmpty == nil || *(f.omitEmpty)
+}
+
+// GetOmitZero returns true if the field should also be omitted from the
+// JSON representation when it has been assigned an empty value, which
+// is the case when `OmitEmpty(true)` has been specified.
+func (f *FieldSpec) GetOmitZero() bool {
+	return f.omitEmpty != nil && *(f.omitEmpty)
+}
+
+// HasMethod specifies if the `HasXXX` method should be generated for
+// this field. When unspecified, the value of `GenerateHasMethods` for
+// the object is used.
+func (f *FieldSpec) HasMethod(b bool) *FieldSpec {
+	f.hasMethod = &b
+	return f
+}
+
+// GetHasMethod returns the value specified via `HasMethod`, and a
+// boolean indicating if the value was explicitly specified.
+func (f *FieldSpec) GetHasMethod() (bool, bool) {
+	if f.hasMethod == nil {
+		return false, false
+	}
+	return *(f.hasMethod), true
+}
+
+// GetGenerateHasMethod returns true if the `HasXXX` method should be
+// generated for this field. The argument is used when no value was
+// explicitly specified via `HasMethod`.
+// This exists because templates cannot call `GetHasMethod` directly.
+func (f *FieldSpec) GetGenerateHasMethod(dflt bool) bool {
+	if v, ok := f.GetHasMethod(); ok {
+		return v
+	}
+	return dflt
+}
+
+// Secret specifies that the value of the field must not be exposed
+// by the generated `String` method (see `--with-stringer`). The generated
+// `Walk` method skips the field when --walk-skip-secret is specified.
+func (f *FieldSpec) Secret(b bool) *FieldSpec {
+	f.secret = b
+	return f
+}
+
+// GetSecret returns true if the field was marked as secret.
+func (f *FieldSpec) GetSecret() bool {
+	return f.secret
+}
+
+// ReadOnly specifies that the field must not be modified via typed
+// methods, which is useful for values that are assigned by a server.
+// The getter is generated and the field is decoded from JSON as usual,
+// but the `SetXXX` method, the builder method, and the option for the
+// field are omitted. Unlike `ConstantValue`, the value is not known
+// when the code is generated.
+func (f *FieldSpec) ReadOnly(b bool) *FieldSpec {
+	f.readOnly = b
+	return f
+}
+
+// GetReadOnly returns true if the field was marked as read-only.
+func (f *FieldSpec) GetReadOnly() bool {
+	return f.readOnly
+}
+
+// WriteOnly specifies that the field must not be included in the
+// JSON representation of the object, which is useful for values such
+// as passwords that are accepted as input but must never be echoed back.
+// The field is decoded from JSON and can be set as usual. Combine with
+// `Secret` to also redact the value from the `String` method.
+//
+// A field cannot be both read-only and write-only.
+func (f *FieldSpec) WriteOnly(b bool) *FieldSpec {
+	f.writeOnly = b
+	return f
+}
+
+// GetWriteOnly returns true if the field was marked as write-only.
+func (f *FieldSpec) GetWriteOnly() bool {
+	return f.writeOnly
+}
+
+// YAML specifies the YAML field name. If unspecified, the
+// JSON field name is used.
+func (f *FieldSpec) YAML(s string) *FieldSpec {
+	f.yaml = s
+	return f
+}
+
+// TOML specifies the TOML key name. If unspecified, the
+// JSON field name is used.
+func (f *FieldSpec) TOML(s string) *FieldSpec {
+	f.toml = s
+	return f
+}
+
+// XML specifies the XML element (or attribute) name. If unspecified,
+// the JSON field name is used.
+func (f *FieldSpec) XML(s string) *FieldSpec {
+	f.xml = s
+	return f
+}
+
+// XMLAttr specifies that the field should be represented as an
+// attribute of the element representing the object, instead of
+// a child element. Only fields of scalar types can be attributes.
+func (f *FieldSpec) XMLAttr(b bool) *FieldSpec {
+	f.xmlAttr = b
+	return f
+}
+
+// GetXMLAttr returns true if the field should be represented as an
+// XML attribute.
+func (f *FieldSpec) GetXMLAttr() bool {
+	return f.xmlAttr
+}
+
+// Form specifies the name of the form field (i.e. the key in
+// url.Values). If unspecified, the JSON field name is used.
+func (f *FieldSpec) Form(s string) *FieldSpec {
+	f.form = s
+	return f
+}
+
+// CBORKey specifies an integer key to be used for the field when the
+// object is encoded into CBOR. If unspecified, the JSON field name is
+// used as the key.
+func (f *FieldSpec) CBORKey(n int) *FieldSpec {
+	f.cborKey = &n
+	return f
+}
+
+// GetHasCBORKey returns true if an integer CBOR key has been specified
+// for the field.
+func (f *FieldSpec) GetHasCBORKey() bool {
+	return f.cborKey != nil
+}
+
+// GetCBORKey returns the integer CBOR key of the field. The return
+// value is only meaningful if GetHasCBORKey returns true.
+func (f *FieldSpec) GetCBORKey() int {
+	if f.cborKey == nil {
+		return 0
+	}
+	return *f.cborKey
+}
+
 func (f *FieldSpec) GetUnexportedName() string {
+	// the default value is not stored in the field, as getters may be
+	// called concurrently while objects are being rendered
 	if f.unexportedName == "" {
-		f.unexportedName = xstrings.Camel(f.name, xstrings.WithLowerCamel(true))
+		return xstrings.Camel(f.name, xstrings.WithLowerCamel(true))
 	}
 	return f.unexportedName
 }
@@ -719,13 +2533,73 @@ func (f *FieldSpec) GetComment() string {
 	return f.comment
 }
 
+// Deprecated marks the field as deprecated. The generated accessors,
+// builder methods, and options for the field are annotated with
+// a `Deprecated:` comment containing the given message, which is
+// recognized by linters and IDEs.
+func (f *FieldSpec) Deprecated(msg string) *FieldSpec {
+	f.deprecated = &msg
+	return f
+}
+
+// GetDeprecated returns the message specified via `Deprecated`, and
+// a boolean indicating if the field is deprecated.
+func (f *FieldSpec) GetDeprecated() (string, bool) {
+	if f.deprecated == nil {
+		return "", false
+	}
+	return *(f.deprecated), true
+}
+
+// GetIsDeprecated returns true if the field is deprecated.
+// This exists because templates cannot call `GetDeprecated` directly.
+func (f *FieldSpec) GetIsDeprecated() bool {
+	_, ok := f.GetDeprecated()
+	return ok
+}
+
+// GetDeprecationMessage returns the message specified via `Deprecated`.
+// This exists because templates cannot call `GetDeprecated` directly.
+func (f *FieldSpec) GetDeprecationMessage() string {
+	msg, _ := f.GetDeprecated()
+	return msg
+}
+
 func (f *FieldSpec) GetJSON() string {
 	if f.json == "" {
-		f.json = f.GetUnexportedName()
+		return f.GetUnexportedName()
 	}
 	return f.json
 }
 
+func (f *FieldSpec) GetYAML() string {
+	if f.yaml == "" {
+		return f.GetJSON()
+	}
+	return f.yaml
+}
+
+func (f *FieldSpec) GetTOML() string {
+	if f.toml == "" {
+		return f.GetJSON()
+	}
+	return f.toml
+}
+
+func (f *FieldSpec) GetForm() string {
+	if f.form == "" {
+		return f.GetJSON()
+	}
+	return f.form
+}
+
+func (f *FieldSpec) GetXML() string {
+	if f.xml == "" {
+		return f.GetJSON()
+	}
+	return f.xml
+}
+
 func (ts *TypeSpec) GetPointerType() string {
 	return ts.ptrType
 }
@@ -754,13 +2628,63 @@ func (f *FieldSpec) GetIsExtension() bool {
 }
 
 func (f *FieldSpec) GetKeyName(object Interface) string {
+	if f.embedded != "" {
+		// Promoted fields use the constants declared by the embedded
+		// object, which are named according to its own settings
+		return f.getEmbeddedObject(object).GetKeyName(f.GetName())
+	}
 	return object.GetKeyName(f.GetName())
 }
 
+// GetConstantName returns the name of the package-level constant (or
+// variable) that holds the value of a constant field.
+func (f *FieldSpec) GetConstantName(object Interface) string {
+	if f.embedded != "" {
+		// Promoted fields use the constants declared by the embedded
+		// object
+		if name := f.getEmbeddedObject(object).Name(); name != "" {
+			return name + f.GetName() + `Value`
+		}
+		return f.embedded + f.GetName() + `Value`
+	}
+	return object.Name() + f.GetName() + `Value`
+}
+
+// getEmbeddedObject returns the object that f was promoted from. The
+// instance that is processed in the same run as object is preferred
+// over the one that was given to `Embed`, as only the former holds the
+// variables that were assigned by sketch, such as the key name suffix
+func (f *FieldSpec) getEmbeddedObject(object Interface) Interface {
+	if all, ok := object.(interface{ AllSchemas() []Interface }); ok {
+		typ := indirectType(f.embeddedObject)
+		for _, s := range all.AllSchemas() {
+			if indirectType(s) == typ {
+				return s
+			}
+		}
+	}
+	return f.embeddedObject
+}
+
+// GetEmbedded returns the name of the object that this field was
+// promoted from using `Embed`. The empty string is returned for
+// fields declared directly in the object
+func (f *FieldSpec) GetEmbedded() string {
+	return f.embedded
+}
+
 // ConstantValue sets the string value that should be used
 // when fetching this field. When ConstantValue is specified,
 // calling `Set` on this field would be a no-op (no error
 // is returned)
+//
+// The value must be a Go expression (e.g. `"foo"` for a string).
+// It is declared once per object as a package-level `const` if
+// the type of the field supports it (see `TypeSpec.SupportsConst`),
+// or as a package-level `var` otherwise, and no storage is allocated
+// for the field in each instance. The name of the constant is
+// the object name followed by the field name and `Value` (see
+// `GetConstantName`)
 func (f *FieldSpec) ConstantValue(s string) *FieldSpec {
 	f.constant = &s
 	return f
@@ -773,3 +2697,456 @@ func (f *FieldSpec) GetIsConstant() bool {
 func (f *FieldSpec) GetConstantValue() string {
 	return *(f.constant)
 }
+
+// Default sets the value that should be stored in the field when
+// the user never explicitly sets a value for it. The default is applied
+// by the generated Builder upon calling `Build()`, only if the field
+// is still unset at that point, as well as by the constructor generated
+// by --with-constructor. Explicitly setting the zero value
+// (e.g. `0` or `""`) counts as setting the field, and the default
+// will not be applied.
+//
+// The value is embedded in the generated code using the `%#v` verb,
+// so it must be a value whose Go-syntax representation is valid Go code
+// for the field's apparent type. For fields whose type implements
+// `AcceptValue`, the value is passed to `AcceptValue` instead.
+func (f *FieldSpec) Default(v interface{}) *FieldSpec {
+	f.hasDefault = true
+	f.defaultValue = v
+	return f
+}
+
+// GetDefault returns the default value for this field, and a boolean
+// indicating if a default value was specified
+func (f *FieldSpec) GetDefault() (interface{}, bool) {
+	return f.defaultValue, f.hasDefault
+}
+
+// GetHasDefault returns true if a default value was specified.
+// This exists because templates cannot call `GetDefault` directly.
+func (f *FieldSpec) GetHasDefault() bool {
+	return f.hasDefault
+}
+
+// GetDefaultValue returns the default value for this field.
+// Use `GetHasDefault` to check if a default value was specified.
+func (f *FieldSpec) GetDefaultValue() interface{} {
+	return f.defaultValue
+}
+
+// Example sets a sample value for the field, which is embedded in the
+// generated JSON Schema documents (as `examples`) and OpenAPI schemas (as
+// `example`), and is available to custom templates via `GetExampleValue`.
+// It does not affect the generated code otherwise.
+//
+// The value must be of the field's apparent type (e.g. `int64(1)` for
+// fields created via `schema.Int64`), or for enums, one of the values
+// accepted by the field. Otherwise generating the code fails.
+func (f *FieldSpec) Example(v interface{}) *FieldSpec {
+	f.hasExample = true
+	f.example = v
+	return f
+}
+
+// GetExample returns the example value for this field, and a boolean
+// indicating if an example value was specified
+func (f *FieldSpec) GetExample() (interface{}, bool) {
+	return f.example, f.hasExample
+}
+
+// GetHasExample returns true if an example value was specified.
+// This exists because templates cannot call `GetExample` directly.
+func (f *FieldSpec) GetHasExample() bool {
+	return f.hasExample
+}
+
+// GetExampleValue returns the example value for this field.
+// Use `GetHasExample` to check if an example value was specified.
+func (f *FieldSpec) GetExampleValue() interface{} {
+	return f.example
+}
+
+// reByteAlias matches the aliases of builtin types that reflection
+// reports by the names of the types that they refer to
+var reByteAlias = regexp.MustCompile(`\b(?:byte|rune)\b`)
+
+// GetIsExampleValid returns true if no example value was specified, or if
+// the example value is of the field's apparent type. Any value is accepted
+// for interfaces. For enums, the value must also be one of the values
+// accepted by the field.
+func (f *FieldSpec) GetIsExampleValid() bool {
+	if !f.hasExample {
+		return true
+	}
+
+	typ := f.GetEnumBaseType()
+	if typ.GetIsInterface() {
+		return true
+	}
+	if f.example == nil {
+		return false
+	}
+
+	expected := reByteAlias.ReplaceAllStringFunc(typ.GetApparentType(), func(s string) string {
+		if s == `byte` {
+			return `uint8`
+		}
+		return `int32`
+	})
+	if typeName(reflect.TypeOf(f.example)) != expected {
+		return false
+	}
+
+	if f.enum == nil {
+		return true
+	}
+	for _, v := range f.enum {
+		if fmt.Sprint(v) == fmt.Sprint(f.example) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetJSONSchema returns the JSON Schema describing the JSON representation
+// of the field, including the constraints that are checked by the generated
+// `Validate` method. This is used when generating JSON Schema documents
+// (see `--with-jsonschema`).
+func (f *FieldSpec) GetJSONSchema() map[string]interface{} {
+	// enums are represented by their underlying type
+	typ := f.GetEnumBaseType()
+
+	var s map[string]interface{}
+	switch {
+	case f.GetMarshalJSONFunc() != "":
+		// the representation is up to the user
+		s = make(map[string]interface{})
+	case f.GetJSONString():
+		s = map[string]interface{}{`type`: `string`}
+	case typ.GetJSONEncoding() == JSONEncodingArray:
+		s = map[string]interface{}{
+			`type`:  `array`,
+			`items`: map[string]interface{}{`type`: `integer`},
+		}
+	case typ.GetJSONEncoding() != "":
+		s = map[string]interface{}{`type`: `string`}
+	default:
+		s = typ.GetJSONSchema()
+	}
+
+	if f.comment != "" {
+		s[`description`] = f.comment
+	}
+	if f.GetIsDeprecated() {
+		s[`deprecated`] = true
+	}
+	if f.writeOnly {
+		s[`writeOnly`] = true
+	}
+
+	lenPrefix := `Length`
+	if s[`type`] == `array` {
+		lenPrefix = `Items`
+	}
+	if f.minLen != nil {
+		s[`min`+lenPrefix] = *(f.minLen)
+	}
+	if f.maxLen != nil {
+		s[`max`+lenPrefix] = *(f.maxLen)
+	}
+	if f.pattern != "" {
+		s[`pattern`] = f.pattern
+	}
+	if f.min != nil {
+		s[`minimum`] = *(f.min)
+	}
+	if f.max != nil {
+		s[`maximum`] = *(f.max)
+	}
+	if f.enum != nil {
+		s[`enum`] = f.enum
+	}
+	// the representation of values that are stored in custom types, or
+	// encoded in custom ways may differ from that of the example
+	if f.hasExample && f.GetMarshalJSONFunc() == "" && typ.GetJSONEncoding() == "" && typ.GetAcceptValueMethodName() == "" {
+		example := f.example
+		if f.GetJSONString() {
+			example = fmt.Sprint(example)
+		}
+		s[`examples`] = []interface{}{example}
+	}
+	return s
+}
+
+// openAPIFormats maps apparent types to the OpenAPI formats that are
+// used for them, along with the types in JSON that the formats apply to
+var openAPIFormats = map[string][2]string{
+	`int32`:     {`integer`, `int32`},
+	`int64`:     {`integer`, `int64`},
+	`float32`:   {`number`, `float`},
+	`float64`:   {`number`, `double`},
+	`[]byte`:    {`string`, `byte`},
+	`time.Time`: {`string`, `date-time`},
+}
+
+// GetOpenAPISchema returns the OpenAPI schema describing the JSON
+// representation of the field. This is used when generating OpenAPI
+// component schemas (see `--with-openapi`).
+//
+// It is based on the schema returned by `GetJSONSchema`, with the type and
+// the format specified via `TypeSpec.OpenAPIType` and `TypeSpec.OpenAPIFormat`.
+// Unless specified, formats are derived from the apparent type, as long as
+// they agree with the type in JSON: `int32`, `int64`, `float` (float32),
+// `double` (float64), `byte` (base64 encoded byte slices), and `date-time`
+// (time.Time, which encoding/json encodes as an RFC3339 string).
+func (f *FieldSpec) GetOpenAPISchema() map[string]interface{} {
+	s := f.GetJSONSchema()
+	// OpenAPI 3.0 only allows a single example
+	if examples, ok := s[`examples`].([]interface{}); ok {
+		delete(s, `examples`)
+		s[`example`] = examples[0]
+	}
+	typ := f.GetEnumBaseType()
+	if v := typ.GetOpenAPIType(); v != "" {
+		s[`type`] = v
+	}
+	if v := typ.GetOpenAPIFormat(); v != "" {
+		s[`format`] = v
+		return s
+	}
+
+	hint, ok := openAPIFormats[typ.GetApparentType()]
+	if !ok || f.GetMarshalJSONFunc() != "" {
+		return s
+	}
+	if _, ok := s[`type`]; !ok && typ.GetApparentType() == `time.Time` {
+		s[`type`] = `string`
+	}
+	if s[`type`] != hint[0] {
+		return s
+	}
+	if hint[1] == `byte` {
+		if enc := typ.GetJSONEncoding(); enc != "" && enc != JSONEncodingBase64 {
+			return s
+		}
+	}
+	s[`format`] = hint[1]
+	return s
+}
+
+// MinLen specifies the minimum length of the field value. This constraint
+// is checked by the generated `Validate` method, and only makes sense
+// for types that support the `len()` operation, such as strings and slices.
+func (f *FieldSpec) MinLen(v int) *FieldSpec {
+	f.minLen = &v
+	return f
+}
+
+// GetHasMinLen returns true if a minimum length constraint was specified
+func (f *FieldSpec) GetHasMinLen() bool {
+	return f.minLen != nil
+}
+
+func (f *FieldSpec) GetMinLen() int {
+	return *(f.minLen)
+}
+
+// MaxLen specifies the maximum length of the field value. This constraint
+// is checked by the generated `Validate` method, and only makes sense
+// for types that support the `len()` operation, such as strings and slices.
+func (f *FieldSpec) MaxLen(v int) *FieldSpec {
+	f.maxLen = &v
+	return f
+}
+
+// GetHasMaxLen returns true if a maximum length constraint was specified
+func (f *FieldSpec) GetHasMaxLen() bool {
+	return f.maxLen != nil
+}
+
+func (f *FieldSpec) GetMaxLen() int {
+	return *(f.maxLen)
+}
+
+// Pattern specifies a regular expression that the value of a string
+// field must match. The regular expression is compiled once in
+// a package-level variable in the generated code, and is checked by
+// the generated `Validate` method.
+//
+// The generated code uses the "regexp" package, which is imported
+// automatically.
+func (f *FieldSpec) Pattern(s string) *FieldSpec {
+	f.pattern = s
+	return f
+}
+
+func (f *FieldSpec) GetPattern() string {
+	return f.pattern
+}
+
+// Min specifies the minimum value of a numeric field. This constraint
+// is checked by the generated `Validate` method. The value must not
+// have a fractional part if the field is an integer.
+func (f *FieldSpec) Min(v float64) *FieldSpec {
+	f.min = &v
+	return f
+}
+
+// GetHasMin returns true if a minimum value constraint was specified
+func (f *FieldSpec) GetHasMin() bool {
+	return f.min != nil
+}
+
+func (f *FieldSpec) GetMin() float64 {
+	return *(f.min)
+}
+
+// GetMinLiteral returns the minimum value as a Go literal that can be
+// compared against the value of the field. The empty string is returned
+// if the field is an integer, and the value has a fractional part
+func (f *FieldSpec) GetMinLiteral() string {
+	return f.boundLiteral(f.GetMin())
+}
+
+// Max specifies the maximum value of a numeric field. This constraint
+// is checked by the generated `Validate` method. The value must not
+// have a fractional part if the field is an integer.
+func (f *FieldSpec) Max(v float64) *FieldSpec {
+	f.max = &v
+	return f
+}
+
+// GetHasMax returns true if a maximum value constraint was specified
+func (f *FieldSpec) GetHasMax() bool {
+	return f.max != nil
+}
+
+func (f *FieldSpec) GetMax() float64 {
+	return *(f.max)
+}
+
+// GetMaxLiteral returns the maximum value as a Go literal. See
+// `GetMinLiteral` for details
+func (f *FieldSpec) GetMaxLiteral() string {
+	return f.boundLiteral(f.GetMax())
+}
+
+func (f *FieldSpec) boundLiteral(v float64) string {
+	if !f.GetEnumBaseType().GetIsInteger() {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	if v != math.Trunc(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Enum restricts the values of a string or integer field to the given
+// values, which must be strings or integers, respectively. A named type
+// (see `EnumTypeName`) is generated for the field along with a constant
+// for each value, and the accessors and the builder use the named type.
+//
+// Values that are not declared are rejected by `Set` and by the generated
+// `Validate` method. Decoding from JSON accepts any value of the underlying
+// type, so that documents containing values added in the future can still
+// be read, and `Validate` should be called to reject them.
+func (f *FieldSpec) Enum(values ...interface{}) *FieldSpec {
+	f.enum = append(f.enum, values...)
+	return f
+}
+
+// GetEnum returns the values specified via `Enum`
+func (f *FieldSpec) GetEnum() []interface{} {
+	return f.enum
+}
+
+// GetIsEnum returns true if the values of the field have been restricted
+// via `Enum`
+func (f *FieldSpec) GetIsEnum() bool {
+	return f.enum != nil
+}
+
+// EnumTypeName specifies the name of the type generated for a field
+// declared via `Enum`. By default the name of the object is followed
+// by the name of the field (e.g. `ObjectStatus`).
+func (f *FieldSpec) EnumTypeName(s string) *FieldSpec {
+	f.enumTypeName = s
+	return f
+}
+
+// GetEnumTypeName returns the name of the type generated for a field
+// declared via `Enum`
+func (f *FieldSpec) GetEnumTypeName() string {
+	return f.enumTypeName
+}
+
+// GetEnumBaseType returns the type that the field was declared with,
+// which is the underlying type of the type generated for the field
+func (f *FieldSpec) GetEnumBaseType() *TypeSpec {
+	return f.typ
+}
+
+// EnumValue describes one of the values that a field declared via `Enum`
+// accepts
+type EnumValue struct {
+	// ConstantName is the name of the constant declared for the value.
+	// It is empty if no valid name could be derived from the value.
+	ConstantName string
+	// Literal is the value as a Go literal. It is empty if the value
+	// is not of the underlying type of the field.
+	Literal string
+	Value   interface{}
+}
+
+// GetEnumValues returns the values specified via `Enum`, along with the
+// names of their constants, which consist of the name of the type and the
+// value converted to PascalCase (e.g. `ObjectStatusInProgress` for
+// "in-progress"). Negative integers are prefixed with `Minus`, and the
+// empty string is named `Empty`.
+func (f *FieldSpec) GetEnumValues() []*EnumValue {
+	isString := f.typ.GetApparentType() == `string`
+	isInteger := f.typ.GetIsInteger()
+	values := make([]*EnumValue, len(f.enum))
+	for i, v := range f.enum {
+		ev := &EnumValue{Value: v}
+		values[i] = ev
+
+		var suffix string
+		rv := reflect.ValueOf(v)
+		switch {
+		case isString && rv.Kind() == reflect.String:
+			ev.Literal = strconv.Quote(rv.String())
+			suffix = `Empty`
+			if rv.String() != "" {
+				suffix = strings.Map(func(r rune) rune {
+					if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+						return r
+					}
+					return -1
+				}, xstrings.Camel(rv.String()))
+			}
+		case isInteger && rv.CanInt():
+			ev.Literal = strconv.FormatInt(rv.Int(), 10)
+			suffix = ev.Literal
+			if rv.Int() < 0 {
+				suffix = `Minus` + strconv.FormatInt(-rv.Int(), 10)
+			}
+		case isInteger && rv.CanUint():
+			ev.Literal = strconv.FormatUint(rv.Uint(), 10)
+			suffix = ev.Literal
+		default:
+			continue
+		}
+		if suffix != "" {
+			ev.ConstantName = f.GetEnumTypeName() + suffix
+		}
+	}
+	return values
+}
+
+// GetHasConstraints returns true if any of the constraints checked by
+// the generated `Validate` method (MinLen, MaxLen, Pattern, Min, Max, Enum)
+// was specified for this field.
+func (f *FieldSpec) GetHasConstraints() bool {
+	return f.minLen != nil || f.maxLen != nil || f.pattern != "" || f.min != nil || f.max != nil || f.enum != nil
+}
@@ -1,9 +1,13 @@
 package schema_test
 
 import (
+	"bytes"
+	"database/sql"
 	"fmt"
+	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/lestrrat-go/sketch/schema"
 	"github.com/stretchr/testify/require"
@@ -43,3 +47,624 @@ func TestType(t *testing.T) {
 	require.Equal(t, `AcceptValue`, ti.GetAcceptValueMethodName())
 	require.Equal(t, ti.GetApparentType(), `[]string`)
 }
+
+func TestFieldDefault(t *testing.T) {
+	f := schema.Int("Port")
+	_, ok := f.GetDefault()
+	require.False(t, ok, `default should not be set`)
+
+	f.Default(8080)
+	v, ok := f.GetDefault()
+	require.True(t, ok, `default should be set`)
+	require.Equal(t, 8080, v)
+}
+
+type Names []string
+
+func TestTypeNamedSlice(t *testing.T) {
+	ti := schema.Type(Names(nil))
+	require.Equal(t, `schema_test.Names`, ti.GetName())
+	require.Equal(t, `schema_test.Names`, ti.GetRawType())
+	require.True(t, ti.GetIsSlice())
+}
+
+func TestFieldHasMethod(t *testing.T) {
+	f := schema.String("Name")
+	_, ok := f.GetHasMethod()
+	require.False(t, ok, `override should not be set`)
+	require.True(t, f.GetGenerateHasMethod(true), `global value should be used`)
+	require.False(t, f.GetGenerateHasMethod(false), `global value should be used`)
+
+	f.HasMethod(false)
+	v, ok := f.GetHasMethod()
+	require.True(t, ok, `override should be set`)
+	require.False(t, v)
+	require.False(t, f.GetGenerateHasMethod(true), `override should take precedence`)
+}
+
+func TestTypeMap(t *testing.T) {
+	t.Run("Type", func(t *testing.T) {
+		ti := schema.Type(map[string][]int(nil))
+		require.True(t, ti.GetIsMap())
+		require.Equal(t, `string`, ti.GetMapKey())
+		require.Equal(t, `[]int`, ti.GetMapElement())
+		require.Equal(t, `[]int`, ti.GetElement())
+		require.Equal(t, `map[string][]int`, ti.GetApparentType())
+	})
+	t.Run("TypeName", func(t *testing.T) {
+		ti := schema.TypeName(`map[[2]string]map[string]int`)
+		require.True(t, ti.GetIsMap())
+		require.Equal(t, `[2]string`, ti.GetMapKey())
+		require.Equal(t, `map[string]int`, ti.GetMapElement())
+		require.Equal(t, `map[[2]string]map[string]int`, ti.GetApparentType())
+	})
+	t.Run("Custom storage", func(t *testing.T) {
+		ti := schema.TypeName(`LabelMap`).MapKey(`string`).MapElement(`int`)
+		require.Equal(t, `LabelMap`, ti.GetRawType())
+		require.Equal(t, `int`, ti.GetElement())
+		require.Equal(t, `map[string]int`, ti.GetApparentType())
+	})
+}
+
+type Set[T comparable] map[T]struct{}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func TestTypeGeneric(t *testing.T) {
+	t.Run("TypeName", func(t *testing.T) {
+		ti := schema.TypeName(`Set[string]`)
+		require.Equal(t, `Set[string]`, ti.GetApparentType())
+		require.Equal(t, `Set[string]`, ti.GetRawType())
+		require.Equal(t, `*Set[string]`, ti.GetPointerType())
+		require.Equal(t, `string`, ti.GetElement())
+		require.False(t, ti.GetIsSlice())
+		require.False(t, ti.GetIsMap())
+	})
+	t.Run("TypeName with pointer", func(t *testing.T) {
+		ti := schema.TypeName(`*mypkg.Set[map[string][]int]`)
+		require.Equal(t, `mypkg.Set[map[string][]int]`, ti.GetRawType())
+		require.Equal(t, `*mypkg.Set[map[string][]int]`, ti.GetPointerType())
+		require.Equal(t, `map[string][]int`, ti.GetElement())
+	})
+	t.Run("TypeName with multiple type arguments", func(t *testing.T) {
+		ti := schema.TypeName(`Pair[string, []int]`)
+		require.Equal(t, `Pair[string, []int]`, ti.GetRawType())
+		require.Equal(t, `sketch.UnknownType`, ti.GetElement(), `element cannot be determined from multiple type arguments`)
+	})
+	t.Run("Type", func(t *testing.T) {
+		ti := schema.Type(Set[string]{})
+		require.Equal(t, `schema_test.Set[string]`, ti.GetApparentType())
+		require.True(t, ti.GetIsMap())
+		require.Equal(t, `string`, ti.GetMapKey())
+
+		ti = schema.Type(Pair[string, *schema.FieldSpec]{})
+		require.Equal(t, `schema_test.Pair[string,*schema.FieldSpec]`, ti.GetRawType(), `type arguments should be referred to by package names`)
+	})
+}
+
+type Metadata struct {
+	schema.Base
+}
+
+func (Metadata) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String(`Owner`).Extra(`foo`, `bar`),
+		schema.Int(`Revision`),
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	fields := schema.Embed(Metadata{})
+	require.Len(t, fields, 2)
+	require.Equal(t, `Owner`, fields[0].GetName())
+	require.Equal(t, `owner`, fields[0].GetJSON())
+	require.Equal(t, `Revision`, fields[1].GetName())
+	require.Equal(t, `bar`, fields[0].GetExtra(`foo`))
+	for _, f := range fields {
+		require.Equal(t, `Metadata`, f.GetEmbedded())
+	}
+	require.Equal(t, `OwnerKey`, fields[0].GetKeyName(&Metadata{}))
+}
+
+func TestAccessorPrefix(t *testing.T) {
+	var object Metadata
+	require.Equal(t, ``, object.AccessorPrefix(), `no prefix should be added by default`)
+
+	object.Variables = map[string]interface{}{`DefaultAccessorPrefix`: `Get`}
+	require.Equal(t, `Get`, object.AccessorPrefix())
+}
+
+func TestKeyNameSuffix(t *testing.T) {
+	var object Metadata
+	require.Equal(t, `Key`, object.KeyNameSuffix(), `default suffix should be Key`)
+	require.Equal(t, `OwnerKey`, object.GetKeyName(`Owner`))
+
+	object.Variables = map[string]interface{}{`DefaultKeyNameSuffix`: `Field`}
+	require.Equal(t, `OwnerField`, object.GetKeyName(`Owner`))
+
+	object.Variables[`DefaultKeyNameSuffix`] = ``
+	require.Equal(t, `Owner`, object.GetKeyName(`Owner`), `suffix may be empty`)
+
+	fields := schema.Embed(Metadata{})
+	require.Equal(t, `OwnerKey`, fields[0].GetKeyName(&object), `promoted fields should use the suffix of the embedded object`)
+
+	// the embedded object that is processed in the same run is used,
+	// as it holds the variables that sketch assigned to it
+	var embedded Metadata
+	embedded.Variables = map[string]interface{}{
+		`DefaultName`:          `Metadata`,
+		`DefaultKeyNamePrefix`: `Metadata`,
+		`DefaultKeyNameSuffix`: `Field`,
+	}
+	var embedding schema.Base
+	embedding.Variables = map[string]interface{}{
+		`DefaultKeyNameSuffix`: ``,
+		`DefaultAllSchemas`:    []schema.Interface{&embedded},
+	}
+	require.Equal(t, `MetadataOwnerField`, fields[0].GetKeyName(&embedding))
+	require.Equal(t, `MetadataOwnerValue`, fields[0].GetConstantName(&embedding))
+}
+
+func TestFieldDeprecated(t *testing.T) {
+	f := schema.String("LegacyID")
+	_, ok := f.GetDeprecated()
+	require.False(t, ok, `field should not be deprecated`)
+	require.False(t, f.GetIsDeprecated())
+
+	f.Deprecated("use ID instead")
+	msg, ok := f.GetDeprecated()
+	require.True(t, ok, `field should be deprecated`)
+	require.Equal(t, `use ID instead`, msg)
+	require.True(t, f.GetIsDeprecated())
+	require.Equal(t, `use ID instead`, f.GetDeprecationMessage())
+}
+
+func TestFieldJSONAliases(t *testing.T) {
+	f := schema.String("UserID").JSON("user_id")
+	require.Empty(t, f.GetJSONAliases())
+
+	f.JSONAliases("userId").JSONAliases("uid")
+	require.Equal(t, []string{"userId", "uid"}, f.GetJSONAliases())
+	require.Equal(t, "user_id", f.GetJSON())
+}
+
+func TestFieldJSONString(t *testing.T) {
+	f := schema.Field("ID", int64(0))
+	require.False(t, f.GetJSONString())
+	require.True(t, f.GetType().GetIsNumeric())
+
+	f.JSONString(true)
+	require.True(t, f.GetJSONString())
+
+	require.False(t, schema.String("Name").GetType().GetIsNumeric())
+}
+
+func TestFieldTOML(t *testing.T) {
+	f := schema.String("Host").JSON("host_name")
+	require.Equal(t, `host_name`, f.GetTOML(), `JSON field name should be used by default`)
+
+	f.TOML("hostname")
+	require.Equal(t, `hostname`, f.GetTOML())
+	require.Equal(t, `host_name`, f.GetJSON())
+}
+
+func TestNumericFields(t *testing.T) {
+	testcases := []struct {
+		Field   *schema.FieldSpec
+		Type    string
+		ZeroVal string
+	}{
+		{Field: schema.Int("Count"), Type: `int`, ZeroVal: `0`},
+		{Field: schema.Int32("Count"), Type: `int32`, ZeroVal: `int32(0)`},
+		{Field: schema.Int64("Count"), Type: `int64`, ZeroVal: `int64(0)`},
+		{Field: schema.Uint("Count"), Type: `uint`, ZeroVal: `uint(0)`},
+		{Field: schema.Uint64("Count"), Type: `uint64`, ZeroVal: `uint64(0)`},
+		{Field: schema.Float32("Rate"), Type: `float32`, ZeroVal: `float32(0)`},
+		{Field: schema.Float64("Rate"), Type: `float64`, ZeroVal: `float64(0)`},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Type, func(t *testing.T) {
+			typ := tc.Field.GetType()
+			require.Equal(t, tc.Type, typ.GetApparentType())
+			require.Equal(t, `*`+tc.Type, typ.GetPointerType())
+			require.Equal(t, tc.ZeroVal, typ.GetZeroVal())
+			require.True(t, typ.GetIsNumeric())
+		})
+	}
+}
+
+func TestTypeArray(t *testing.T) {
+	typ := schema.Type([16]byte{})
+	require.Equal(t, `[16]uint8`, typ.GetApparentType())
+	require.Equal(t, `[16]uint8`, typ.GetRawType())
+	require.Equal(t, `*[16]uint8`, typ.GetPointerType(), `arrays should be stored as pointers`)
+	require.Equal(t, `[16]uint8{}`, typ.GetZeroVal())
+	require.Equal(t, schema.JSONEncodingBase64, typ.GetJSONEncoding(), `byte arrays should be encoded as base64 by default`)
+
+	typ.JSONEncoding(schema.JSONEncodingHex)
+	require.Equal(t, schema.JSONEncodingHex, typ.GetJSONEncoding())
+
+	require.Empty(t, schema.Type([4]int{}).GetJSONEncoding(), `non-byte arrays should use encoding/json`)
+}
+
+func TestTypeJSONEncoding(t *testing.T) {
+	require.Empty(t, schema.ByteSliceType.GetJSONEncoding(), `byte slices should be left to encoding/json by default`)
+	require.Empty(t, schema.NativeByteSliceType.GetJSONEncoding(), `byte slices should be left to encoding/json by default`)
+
+	typ := schema.Type([]byte(nil)).JSONEncoding(schema.JSONEncodingArray)
+	require.Equal(t, schema.JSONEncodingArray, typ.GetJSONEncoding())
+	require.True(t, typ.GetIsSlice())
+	require.False(t, typ.GetIsArray())
+	require.True(t, schema.Type([4]byte{}).GetIsArray())
+}
+
+func TestFieldVariadicAdder(t *testing.T) {
+	f := schema.Field("Tags", []string(nil))
+	require.False(t, f.GetVariadicAdder(), `variadic adder should be disabled by default`)
+	require.True(t, f.GetType().SliceStyleInitializerArgument())
+
+	f.VariadicAdder(true)
+	require.True(t, f.GetVariadicAdder())
+}
+
+func TestTypeResetMethodName(t *testing.T) {
+	typ := schema.Type(bytes.Buffer{})
+	require.Empty(t, typ.GetResetMethodName(), `reset method should be empty by default`)
+
+	typ.ResetMethodName(`Reset`)
+	require.Equal(t, `Reset`, typ.GetResetMethodName())
+}
+
+func TestTypeSupportsConst(t *testing.T) {
+	require.True(t, schema.String(`Kind`).GetType().GetSupportsConst())
+	require.True(t, schema.Type(true).GetSupportsConst())
+	require.True(t, schema.Type(time.Duration(0)).GetSupportsConst(), `named basic types can be declared as constants`)
+	require.False(t, schema.Type([]string(nil)).GetSupportsConst())
+	require.False(t, schema.TypeName(`mypkg.Kind`).GetSupportsConst())
+
+	f := schema.String(`Kind`).ConstantValue(`"thing"`)
+	require.Equal(t, `ThingKindValue`, f.GetConstantName(&schema.Base{Variables: map[string]interface{}{`DefaultName`: `Thing`}}))
+}
+
+func TestFieldJSONFuncs(t *testing.T) {
+	f := schema.Field(`Hosts`, []string(nil))
+	require.Empty(t, f.GetMarshalJSONFunc())
+	require.Empty(t, f.GetUnmarshalJSONFunc())
+
+	f.MarshalJSONFunc(`marshalHosts`).UnmarshalJSONFunc(`unmarshalHosts`)
+	require.Equal(t, `marshalHosts`, f.GetMarshalJSONFunc())
+	require.Equal(t, `unmarshalHosts`, f.GetUnmarshalJSONFunc())
+}
+
+func TestFieldOmitEmpty(t *testing.T) {
+	f := schema.String(`Name`)
+	require.True(t, f.GetOmitEmpty(), `unset fields are omitted by default`)
+	require.False(t, f.GetOmitZero(), `empty values are emitted by default`)
+
+	f.OmitEmpty(true)
+	require.True(t, f.GetOmitEmpty())
+	require.True(t, f.GetOmitZero())
+
+	f.OmitEmpty(false)
+	require.False(t, f.GetOmitEmpty())
+	require.False(t, f.GetOmitZero())
+}
+
+func TestFieldReadOnly(t *testing.T) {
+	f := schema.String(`ID`)
+	require.False(t, f.GetReadOnly(), `fields are writable by default`)
+	f.ReadOnly(true)
+	require.True(t, f.GetReadOnly())
+}
+
+func TestFieldWriteOnly(t *testing.T) {
+	f := schema.String(`Password`)
+	require.False(t, f.GetWriteOnly(), `fields are readable by default`)
+	require.NotContains(t, f.GetJSONSchema(), `writeOnly`)
+
+	f.WriteOnly(true)
+	require.True(t, f.GetWriteOnly())
+	require.Equal(t, true, f.GetJSONSchema()[`writeOnly`])
+}
+
+func TestFieldXML(t *testing.T) {
+	f := schema.String(`DisplayName`).JSON(`display_name`)
+	require.Equal(t, `display_name`, f.GetXML(), `XML name should default to the JSON name`)
+	require.False(t, f.GetXMLAttr())
+
+	f.XML(`name`).XMLAttr(true)
+	require.Equal(t, `name`, f.GetXML())
+	require.True(t, f.GetXMLAttr())
+}
+
+func TestFieldForm(t *testing.T) {
+	f := schema.String(`DisplayName`).JSON(`display_name`)
+	require.Equal(t, `display_name`, f.GetForm(), `form field name should default to the JSON name`)
+
+	f.Form(`name`)
+	require.Equal(t, `name`, f.GetForm())
+}
+
+func TestTypeTextMarshaler(t *testing.T) {
+	require.True(t, schema.Type(time.Time{}).GetTextMarshaler(), `time.Time should be detected as a text marshaler`)
+	require.False(t, schema.Type(time.Duration(0)).GetTextMarshaler())
+	require.True(t, schema.TimeType.GetTextMarshaler())
+	require.False(t, schema.Type(time.Time{}).ApparentType(`string`).GetTextMarshaler(), `overriding the apparent type should reset the detected value`)
+	require.True(t, schema.TypeName(`mypkg.ID`).TextMarshaler(true).GetTextMarshaler())
+}
+
+func TestTypeSQLScannable(t *testing.T) {
+	require.True(t, schema.Type(sql.NullString{}).GetSQLScannable(), `sql.NullString should be detected as scannable`)
+	require.True(t, schema.Type(&sql.NullInt64{}).GetSQLScannable(), `pointers to scannable types should be detected`)
+	require.False(t, schema.Type(time.Time{}).GetSQLScannable())
+	require.False(t, schema.TypeName(`mypkg.Money`).GetSQLScannable())
+	require.True(t, schema.TypeName(`mypkg.Money`).SQLScannable(true).GetSQLScannable())
+}
+
+func TestTypeImports(t *testing.T) {
+	require.Equal(t, []string{`time`}, schema.Type(time.Time{}).GetImports())
+	require.Equal(t, []string{`database/sql`, `time`}, schema.Type(map[time.Month][]*sql.NullString(nil)).GetImports())
+	require.Empty(t, schema.Type(``).GetImports(), `predeclared types should not require imports`)
+	require.Empty(t, schema.TypeName(`mypkg.ID`).GetImports())
+	require.Equal(t, []string{`example.com/mypkg`, `time`}, schema.Type(time.Time{}).Imports(`example.com/mypkg`, `time`, ``).GetImports())
+	require.Equal(t, []string{`github.com/google/uuid`, `github.com/lestrrat-go/sketch/uuidvalue`}, schema.UUIDType.GetImports())
+}
+
+func TestFieldCBORKey(t *testing.T) {
+	f := schema.String(`ID`)
+	require.False(t, f.GetHasCBORKey(), `CBOR key should not be set by default`)
+
+	f.CBORKey(0)
+	require.True(t, f.GetHasCBORKey(), `zero should be a valid CBOR key`)
+	require.Equal(t, 0, f.GetCBORKey())
+
+	f.CBORKey(-3)
+	require.Equal(t, -3, f.GetCBORKey())
+}
+
+func TestTypeGraphQLType(t *testing.T) {
+	testcases := []struct {
+		Type     *schema.TypeSpec
+		Expected string
+	}{
+		{Type: schema.String(`S`).GetType(), Expected: `String`},
+		{Type: schema.Bool(`B`).GetType(), Expected: `Boolean`},
+		{Type: schema.Int64(`I`).GetType(), Expected: `Int`},
+		{Type: schema.Float32(`F`).GetType(), Expected: `Float`},
+		{Type: schema.ByteSlice(`D`).GetType(), Expected: `String`},
+		{Type: schema.Type([]int(nil)), Expected: `[Int]`},
+		{Type: schema.Type(&StringList{}), Expected: `[String]`},
+		{Type: schema.Type(sort.StringSlice(nil)), Expected: `[String]`},
+		{Type: schema.Type(time.Duration(0)), Expected: `Int`},
+		{Type: schema.TypeName(`[]string`), Expected: `[String]`},
+		{Type: schema.Type(map[string]int(nil)), Expected: ``},
+		{Type: schema.TypeName(`mypkg.Point`), Expected: ``},
+		{Type: schema.TypeName(`mypkg.Point`).GraphQLType(`Point`), Expected: `Point`},
+	}
+	for _, tc := range testcases {
+		require.Equal(t, tc.Expected, tc.Type.GetGraphQLType(), `GraphQL type for %s`, tc.Type.GetName())
+	}
+}
+
+func TestBoundLiterals(t *testing.T) {
+	f := schema.Int(`I`).Min(-1).Max(1e6)
+	require.Equal(t, `-1`, f.GetMinLiteral())
+	require.Equal(t, `1000000`, f.GetMaxLiteral(), `integer fields should use integer literals`)
+
+	f = schema.Int(`I`).Min(0.5)
+	require.Equal(t, ``, f.GetMinLiteral(), `fractional bounds cannot be used for integer fields`)
+
+	f = schema.Float64(`F`).Min(0.5).Max(2)
+	require.Equal(t, `0.5`, f.GetMinLiteral())
+	require.Equal(t, `2`, f.GetMaxLiteral())
+}
+
+func TestFieldJSONSchema(t *testing.T) {
+	testcases := []struct {
+		Field    *schema.FieldSpec
+		Expected map[string]interface{}
+	}{
+		{
+			Field:    schema.String(`S`).MinLen(1).MaxLen(8).Pattern(`^[a-z]+$`).Comment(`lower case`),
+			Expected: map[string]interface{}{`type`: `string`, `minLength`: 1, `maxLength`: 8, `pattern`: `^[a-z]+$`, `description`: `lower case`},
+		},
+		{
+			Field:    schema.Int(`I`).Min(0).Max(65535),
+			Expected: map[string]interface{}{`type`: `integer`, `minimum`: float64(0), `maximum`: float64(65535)},
+		},
+		{
+			Field:    schema.Int64(`I`).JSONString(true),
+			Expected: map[string]interface{}{`type`: `string`},
+		},
+		{
+			Field:    schema.Bool(`B`).Deprecated(`use C`),
+			Expected: map[string]interface{}{`type`: `boolean`, `deprecated`: true},
+		},
+		{
+			Field:    schema.ByteSlice(`D`),
+			Expected: map[string]interface{}{`type`: `string`},
+		},
+		{
+			Field:    schema.Field(`D`, schema.Type([16]byte{}).JSONEncoding(schema.JSONEncodingArray)),
+			Expected: map[string]interface{}{`type`: `array`, `items`: map[string]interface{}{`type`: `integer`}},
+		},
+		{
+			Field:    schema.Field(`L`, []string(nil)).MinLen(1),
+			Expected: map[string]interface{}{`type`: `array`, `items`: map[string]interface{}{`type`: `string`}, `minItems`: 1},
+		},
+		{
+			Field:    schema.Field(`M`, map[string]float64(nil)),
+			Expected: map[string]interface{}{`type`: `object`, `additionalProperties`: map[string]interface{}{`type`: `number`}},
+		},
+		{
+			Field:    schema.Field(`N`, sort.StringSlice(nil)),
+			Expected: map[string]interface{}{`type`: `array`, `items`: map[string]interface{}{`type`: `string`}},
+		},
+		{
+			Field:    schema.Time(`T`),
+			Expected: map[string]interface{}{`type`: `integer`},
+		},
+		{
+			Field:    schema.RawJSON(`R`),
+			Expected: map[string]interface{}{},
+		},
+		{
+			Field:    schema.Field(`P`, schema.TypeName(`mypkg.Point`)),
+			Expected: map[string]interface{}{},
+		},
+		{
+			Field:    schema.Field(`P`, schema.TypeName(`mypkg.Point`).JSONSchemaType(`object`)),
+			Expected: map[string]interface{}{`type`: `object`},
+		},
+	}
+	for _, tc := range testcases {
+		require.Equal(t, tc.Expected, tc.Field.GetJSONSchema(), `JSON Schema for %s`, tc.Field.GetName())
+	}
+}
+
+func TestFieldStructTag(t *testing.T) {
+	testcases := []struct {
+		Field    *schema.FieldSpec
+		Expected string
+	}{
+		{
+			Field:    schema.String(`Name`),
+			Expected: "`json:\"name,omitempty\"`",
+		},
+		{
+			Field:    schema.String(`Name`).JSON(`full_name`).OmitEmpty(false),
+			Expected: "`json:\"full_name\"`",
+		},
+		{
+			Field:    schema.String(`Name`).Tag(`validate`, `required`).Tag(`db`, `name`),
+			Expected: "`json:\"name,omitempty\" db:\"name\" validate:\"required\"`",
+		},
+		{
+			Field:    schema.String(`Name`).Tag(`json`, `-`),
+			Expected: "`json:\"-\"`",
+		},
+		{
+			Field:    schema.String(`Name`).Tag(`doc`, "`quoted`"),
+			Expected: `"json:\"name,omitempty\" doc:\"` + "`quoted`" + `\""`,
+		},
+	}
+	for _, tc := range testcases {
+		require.Equal(t, tc.Expected, tc.Field.GetStructTag(), `struct tag for %s`, tc.Field.GetName())
+	}
+}
+
+func TestFieldOpenAPISchema(t *testing.T) {
+	testcases := []struct {
+		Field    *schema.FieldSpec
+		Expected map[string]interface{}
+	}{
+		{
+			Field:    schema.Int64(`I`).Min(0),
+			Expected: map[string]interface{}{`type`: `integer`, `format`: `int64`, `minimum`: float64(0)},
+		},
+		{
+			Field:    schema.Int64(`I`).JSONString(true),
+			Expected: map[string]interface{}{`type`: `string`},
+		},
+		{
+			Field:    schema.Float64(`F`),
+			Expected: map[string]interface{}{`type`: `number`, `format`: `double`},
+		},
+		{
+			Field:    schema.ByteSlice(`D`),
+			Expected: map[string]interface{}{`type`: `string`, `format`: `byte`},
+		},
+		{
+			Field:    schema.Field(`D`, schema.Type([]byte(nil)).JSONEncoding(schema.JSONEncodingHex)),
+			Expected: map[string]interface{}{`type`: `string`},
+		},
+		{
+			Field:    schema.Field(`T`, time.Time{}),
+			Expected: map[string]interface{}{`type`: `string`, `format`: `date-time`},
+		},
+		{
+			Field:    schema.Time(`T`),
+			Expected: map[string]interface{}{`type`: `integer`},
+		},
+		{
+			Field:    schema.UUID(`U`),
+			Expected: map[string]interface{}{`type`: `string`, `format`: `uuid`},
+		},
+		{
+			Field:    schema.Field(`P`, schema.TypeName(`mypkg.Email`).OpenAPIType(`string`).OpenAPIFormat(`email`)),
+			Expected: map[string]interface{}{`type`: `string`, `format`: `email`},
+		},
+	}
+	for _, tc := range testcases {
+		require.Equal(t, tc.Expected, tc.Field.GetOpenAPISchema(), `OpenAPI schema for %s`, tc.Field.GetName())
+	}
+}
+
+func TestFieldExample(t *testing.T) {
+	f := schema.String(`Name`)
+	_, ok := f.GetExample()
+	require.False(t, ok, `no example by default`)
+	require.True(t, f.GetIsExampleValid(), `fields without examples are valid`)
+	require.NotContains(t, f.GetJSONSchema(), `examples`)
+
+	f.Example(`alice`)
+	v, ok := f.GetExample()
+	require.True(t, ok)
+	require.Equal(t, `alice`, v)
+	require.True(t, f.GetIsExampleValid())
+	require.Equal(t, []interface{}{`alice`}, f.GetJSONSchema()[`examples`])
+	require.Equal(t, map[string]interface{}{`type`: `string`, `example`: `alice`}, f.GetOpenAPISchema())
+
+	require.False(t, schema.Int64(`Port`).Example(8080).GetIsExampleValid(), `untyped constants are ints`)
+	require.True(t, schema.Int64(`Port`).Example(int64(8080)).GetIsExampleValid())
+	require.True(t, schema.Field(`Data`, schema.NativeByteSliceType).Example([]byte(`x`)).GetIsExampleValid(), `byte is an alias of uint8`)
+	require.True(t, schema.Field(`Tags`, []string(nil)).Example([]string{`a`}).GetIsExampleValid())
+	require.False(t, schema.Field(`Tags`, []string(nil)).Example(nil).GetIsExampleValid())
+	require.True(t, schema.Field(`Any`, schema.TypeName(`fmt.Stringer`).IsInterface(true)).Example(1).GetIsExampleValid(), `interfaces accept any value`)
+
+	f = schema.String(`Status`).Enum(`active`, `inactive`).EnumTypeName(`Status`)
+	require.True(t, f.Example(`active`).GetIsExampleValid())
+	require.False(t, f.Example(`deleted`).GetIsExampleValid(), `examples of enums must be one of the values`)
+
+	require.Equal(t, []interface{}{`42`}, schema.Int(`Count`).JSONString(true).Example(42).GetJSONSchema()[`examples`], `numbers encoded as strings`)
+}
+
+func TestFieldEnum(t *testing.T) {
+	f := schema.String(`Status`).Enum(`active`, `in-progress`, ``).EnumTypeName(`ObjectStatus`)
+	require.True(t, f.GetIsEnum())
+	require.True(t, f.GetHasConstraints(), `enums are checked by Validate`)
+
+	typ := f.GetType()
+	require.Equal(t, `ObjectStatus`, typ.GetApparentType())
+	require.Equal(t, `*ObjectStatus`, typ.GetPointerType())
+	require.Equal(t, `ObjectStatus("")`, typ.GetZeroVal())
+	require.True(t, typ.GetSupportsConst())
+	require.Equal(t, `string`, f.GetEnumBaseType().GetApparentType())
+	require.Equal(t, `String`, typ.GetGraphQLType())
+
+	var names, literals []string
+	for _, v := range f.GetEnumValues() {
+		names = append(names, v.ConstantName)
+		literals = append(literals, v.Literal)
+	}
+	require.Equal(t, []string{`ObjectStatusActive`, `ObjectStatusInProgress`, `ObjectStatusEmpty`}, names)
+	require.Equal(t, []string{`"active"`, `"in-progress"`, `""`}, literals)
+	require.Equal(t, map[string]interface{}{`type`: `string`, `enum`: []interface{}{`active`, `in-progress`, ``}}, f.GetJSONSchema())
+
+	f = schema.Int(`Level`).Enum(1, -1, `x`).EnumTypeName(`Level`)
+	values := f.GetEnumValues()
+	require.Equal(t, `Level1`, values[0].ConstantName)
+	require.Equal(t, `LevelMinus1`, values[1].ConstantName)
+	require.Equal(t, `-1`, values[1].Literal)
+	require.Empty(t, values[2].Literal, `values of other types have no literal`)
+	require.Equal(t, `integer`, f.GetOpenAPISchema()[`type`])
+	f.Min(1)
+	require.Equal(t, `1`, f.GetMinLiteral(), `bounds use the underlying type`)
+
+	require.False(t, schema.String(`S`).GetIsEnum())
+}
@@ -1,17 +1,23 @@
 package sketch
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/fs"
+	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/lestrrat-go/multifs"
 	"github.com/lestrrat-go/sketch/schema"
+	"github.com/lestrrat-go/xstrings"
 )
 
 type Template struct {
-	srcs map[string]fs.FS
+	srcs     map[string]fs.FS
+	funcs    template.FuncMap
+	excludes []*regexp.Regexp
 }
 
 func (tmpl *Template) AddFS(prefix string, src fs.FS) {
@@ -21,6 +27,25 @@ func (tmpl *Template) AddFS(prefix string, src fs.FS) {
 	tmpl.srcs[prefix] = src
 }
 
+// AddFuncs registers additional functions to be made available to the
+// templates. The functions must not have the same name as any of the
+// built-in functions, otherwise Build will return an error.
+func (tmpl *Template) AddFuncs(funcs template.FuncMap) {
+	if tmpl.funcs == nil {
+		tmpl.funcs = make(template.FuncMap)
+	}
+	for name, fn := range funcs {
+		tmpl.funcs[name] = fn
+	}
+}
+
+// ExcludeSymbols registers patterns for symbols that should not be
+// generated. The patterns are consulted by the `shouldGenerate` template
+// function in addition to the schema's own `GenerateSymbol` method.
+func (tmpl *Template) ExcludeSymbols(patterns ...*regexp.Regexp) {
+	tmpl.excludes = append(tmpl.excludes, patterns...)
+}
+
 func (tmpl *Template) Build() (*template.Template, error) {
 	var mfs multifs.FS
 	for prefix, sub := range tmpl.srcs {
@@ -47,7 +72,14 @@ func (tmpl *Template) Build() (*template.Template, error) {
 	})
 
 	var tt *template.Template
-	tt = template.New("").Funcs(tmpl.makeFuncs(&tt))
+	funcs := tmpl.makeFuncs(&tt)
+	for name, fn := range tmpl.funcs {
+		if _, ok := funcs[name]; ok {
+			return nil, fmt.Errorf(`user-supplied template function %q conflicts with a built-in function`, name)
+		}
+		funcs[name] = fn
+	}
+	tt = template.New("").Funcs(funcs)
 	_, err := tt.ParseFS(&mfs, files...)
 	if err != nil {
 		return nil, fmt.Errorf(`failed to parse templates: %w`, err)
@@ -58,11 +90,24 @@ func (tmpl *Template) Build() (*template.Template, error) {
 
 func (tmpl *Template) makeFuncs(tt **template.Template) template.FuncMap {
 	return template.FuncMap{
-		"comment":     tmpl.comment(tt),
-		"hasTemplate": tmpl.hasTemplate(tt),
-		"runTemplate": tmpl.runTemplate(tt),
-		"fieldByName": tmpl.fieldByName(tt),
-		"increment":   tmpl.increment(tt),
+		"comment":        tmpl.comment(tt),
+		"hasTemplate":    tmpl.hasTemplate(tt),
+		"runTemplate":    tmpl.runTemplate(tt),
+		"fields":         tmpl.fields(tt),
+		"fieldByName":    tmpl.fieldByName(tt),
+		"imports":        tmpl.imports(tt),
+		"shouldGenerate": tmpl.shouldGenerate(tt),
+		"schemaByName":   tmpl.schemaByName(tt),
+		"typeByName":     tmpl.typeByName(tt),
+		"increment":      tmpl.increment(tt),
+		"errorf":         tmpl.errorf(tt),
+		"toJSON":         tmpl.toJSON(tt),
+		"snake":          tmpl.snake(tt),
+		"kebab":          tmpl.kebab(tt),
+		"camel":          tmpl.camel(tt),
+		"pascal":         tmpl.pascal(tt),
+		"upperFirst":     tmpl.upperFirst(tt),
+		"lowerFirst":     tmpl.lowerFirst(tt),
 	}
 }
 
@@ -102,9 +147,72 @@ func (tmpl *Template) runTemplate(tt **template.Template) func(string, interface
 	}
 }
 
+// fields returns the fields of the schema that are allowed to be generated
+func (tmpl *Template) fields(**template.Template) func(schema.Interface) []*schema.FieldSpec {
+	return generatedFields
+}
+
+func generatedFields(s schema.Interface) []*schema.FieldSpec {
+	fields := s.Fields()
+	for _, f := range fields {
+		// enum types are named after the object unless specified otherwise
+		if f.GetIsEnum() && f.GetEnumTypeName() == "" {
+			f.EnumTypeName(s.Name() + f.GetName())
+		}
+	}
+
+	filter, ok := s.(interface{ GenerateField(string) bool })
+	if !ok {
+		return fields
+	}
+
+	generated := make([]*schema.FieldSpec, 0, len(fields))
+	for _, f := range fields {
+		if filter.GenerateField(f.GetName()) {
+			generated = append(generated, f)
+		}
+	}
+	return generated
+}
+
+// imports returns the sorted and deduplicated import paths for the files
+// generated for the schema, comprising of those declared via `Imports()`,
+// those required by the types of its fields, and the given paths, which
+// are separated by white space. As the generated code may not use all
+// of them, the unused ones are removed after the code has been generated
+func (tmpl *Template) imports(**template.Template) func(schema.Interface, string) []string {
+	return func(s schema.Interface, paths string) []string {
+		seen := make(map[string]struct{})
+		var list []string
+		add := func(path string) {
+			if _, ok := seen[path]; ok || path == "" {
+				return
+			}
+			seen[path] = struct{}{}
+			list = append(list, path)
+		}
+
+		if v, ok := s.(interface{ Imports() []string }); ok {
+			for _, path := range v.Imports() {
+				add(path)
+			}
+		}
+		for _, f := range generatedFields(s) {
+			for _, path := range f.GetType().GetImports() {
+				add(path)
+			}
+		}
+		for _, path := range strings.Fields(paths) {
+			add(path)
+		}
+		sort.Strings(list)
+		return list
+	}
+}
+
 func (tmpl *Template) fieldByName(**template.Template) func(schema.Interface, string) *schema.FieldSpec {
 	return func(s schema.Interface, name string) *schema.FieldSpec {
-		for _, f := range s.Fields() {
+		for _, f := range generatedFields(s) {
 			if f.GetName() == name {
 				return f
 			}
@@ -113,8 +221,99 @@ func (tmpl *Template) fieldByName(**template.Template) func(schema.Interface, st
 	}
 }
 
+func (tmpl *Template) schemaByName(**template.Template) func([]schema.Interface, string) schema.Interface {
+	return func(schemas []schema.Interface, name string) schema.Interface {
+		for _, s := range schemas {
+			if s.Name() == name {
+				return s
+			}
+		}
+		return nil
+	}
+}
+
+// typeByName returns the type whose GetName() matches the given name
+// from the map of types (e.g. `.AllTypes`), or nil if no such type exists
+func (tmpl *Template) typeByName(**template.Template) func(map[string]*schema.TypeSpec, string) *schema.TypeSpec {
+	return func(types map[string]*schema.TypeSpec, name string) *schema.TypeSpec {
+		return types[name]
+	}
+}
+
+// shouldGenerate returns false if the symbol matches any of the excluded
+// patterns, or if the schema's GenerateSymbol method rejects it
+func (tmpl *Template) shouldGenerate(**template.Template) func(schema.Interface, string) bool {
+	return func(s schema.Interface, symbol string) bool {
+		for _, rx := range tmpl.excludes {
+			if rx.MatchString(symbol) {
+				return false
+			}
+		}
+
+		if g, ok := s.(interface{ GenerateSymbol(string) bool }); ok {
+			return g.GenerateSymbol(symbol)
+		}
+		return true
+	}
+}
+
 func (tmpl *Template) increment(**template.Template) func(int) int {
 	return func(v int) int {
 		return v + 1
 	}
 }
+
+func (tmpl *Template) errorf(**template.Template) func(string, ...interface{}) (string, error) {
+	return func(f string, args ...interface{}) (string, error) {
+		return "", fmt.Errorf(f, args...)
+	}
+}
+
+// toJSON encodes the value as JSON
+func (tmpl *Template) toJSON(**template.Template) func(interface{}) (string, error) {
+	return func(v interface{}) (string, error) {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf(`failed to encode value as JSON: %w`, err)
+		}
+		return string(buf), nil
+	}
+}
+
+// snake converts the string to snake_case
+func (tmpl *Template) snake(**template.Template) func(string) string {
+	return func(s string) string {
+		return xstrings.Snake(s)
+	}
+}
+
+// kebab converts the string to kebab-case
+func (tmpl *Template) kebab(**template.Template) func(string) string {
+	return func(s string) string {
+		// converting from snake case handles inputs that already
+		// contain underscores
+		return strings.ReplaceAll(xstrings.Snake(s), `_`, `-`)
+	}
+}
+
+// camel converts the string to camelCase
+func (tmpl *Template) camel(**template.Template) func(string) string {
+	return func(s string) string {
+		return xstrings.Camel(s, xstrings.WithLowerCamel(true))
+	}
+}
+
+// pascal converts the string to PascalCase
+func (tmpl *Template) pascal(**template.Template) func(string) string {
+	return func(s string) string {
+		return xstrings.Camel(s)
+	}
+}
+
+func (tmpl *Template) upperFirst(**template.Template) func(string) string {
+	return xstrings.UcFirst
+}
+
+func (tmpl *Template) lowerFirst(**template.Template) func(string) string {
+	return xstrings.LcFirst
+}
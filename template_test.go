@@ -0,0 +1,168 @@
+package sketch_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/lestrrat-go/sketch"
+	"github.com/lestrrat-go/sketch/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateAddFuncs(t *testing.T) {
+	src := fstest.MapFS{
+		"hello.tmpl": &fstest.MapFile{
+			Data: []byte(`{{ define "hello" }}{{ shout . }}{{ end }}`),
+		},
+	}
+
+	t.Run("user-supplied function", func(t *testing.T) {
+		var tt sketch.Template
+		tt.AddFS("/usr", src)
+		tt.AddFuncs(template.FuncMap{"shout": strings.ToUpper})
+
+		tmpl, err := tt.Build()
+		require.NoError(t, err, `Build should succeed`)
+
+		var sb strings.Builder
+		require.NoError(t, tmpl.ExecuteTemplate(&sb, "hello", "hello"))
+		require.Equal(t, "HELLO", sb.String())
+	})
+	t.Run("conflict with built-in function", func(t *testing.T) {
+		var tt sketch.Template
+		tt.AddFS("/usr", src)
+		tt.AddFuncs(template.FuncMap{
+			"shout":   strings.ToUpper,
+			"comment": strings.ToLower,
+		})
+
+		_, err := tt.Build()
+		require.Error(t, err, `Build should fail`)
+		require.Contains(t, err.Error(), `"comment"`)
+	})
+}
+
+func TestTemplateCasing(t *testing.T) {
+	src := fstest.MapFS{
+		"casing.tmpl": &fstest.MapFile{
+			Data: []byte(`{{ define "casing" }}{{ snake . }} {{ kebab . }} {{ camel . }} {{ pascal . }} {{ upperFirst . }} {{ lowerFirst . }}{{ end }}
+{{ define "main" }}{{ runTemplate "casing" . }}{{ end }}`),
+		},
+	}
+
+	var tt sketch.Template
+	tt.AddFS("/usr", src)
+	tmpl, err := tt.Build()
+	require.NoError(t, err, `Build should succeed`)
+
+	testcases := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "FooBar", Expected: "foo_bar foo-bar fooBar FooBar FooBar fooBar"},
+		{Input: "foo_bar", Expected: "foo_bar foo-bar fooBar FooBar Foo_bar foo_bar"},
+		{Input: "userID", Expected: "user_id user-id userID UserID UserID userID"},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Input, func(t *testing.T) {
+			var sb strings.Builder
+			require.NoError(t, tmpl.ExecuteTemplate(&sb, "main", tc.Input))
+			require.Equal(t, tc.Expected, sb.String())
+		})
+	}
+}
+
+func TestTemplateSchemaByName(t *testing.T) {
+	src := fstest.MapFS{
+		"lookup.tmpl": &fstest.MapFile{
+			Data: []byte(`{{ define "lookup" }}{{ with (schemaByName . "Bar") }}{{ .Name }}{{ else }}none{{ end }}{{ end }}`),
+		},
+	}
+
+	var tt sketch.Template
+	tt.AddFS("/usr", src)
+	tmpl, err := tt.Build()
+	require.NoError(t, err, `Build should succeed`)
+
+	schemas := []schema.Interface{
+		&schema.Base{Variables: map[string]interface{}{"DefaultName": "Foo"}},
+		&schema.Base{Variables: map[string]interface{}{"DefaultName": "Bar"}},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, tmpl.ExecuteTemplate(&sb, "lookup", schemas))
+	require.Equal(t, "Bar", sb.String())
+
+	sb.Reset()
+	require.NoError(t, tmpl.ExecuteTemplate(&sb, "lookup", schemas[:1]))
+	require.Equal(t, "none", sb.String())
+}
+
+type fieldsObject struct {
+	schema.Base
+}
+
+func (fieldsObject) Fields() []*schema.FieldSpec {
+	return []*schema.FieldSpec{
+		schema.String("Foo"),
+		schema.String("Bar"),
+		schema.String("Baz"),
+	}
+}
+
+func TestTemplateFields(t *testing.T) {
+	src := fstest.MapFS{
+		"fields.tmpl": &fstest.MapFile{
+			Data: []byte(`{{ define "fields" }}{{ range $i, $f := (fields .) }}{{ $f.GetName }};{{ end }}{{ if (fieldByName . "Bar") }}found{{ end }}{{ end }}`),
+		},
+	}
+
+	var tt sketch.Template
+	tt.AddFS("/usr", src)
+	tmpl, err := tt.Build()
+	require.NoError(t, err, `Build should succeed`)
+
+	object := &fieldsObject{
+		Base: schema.Base{
+			Variables: map[string]interface{}{
+				"DefaultGenerateField": func(s string) bool { return s != "Bar" },
+			},
+		},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, tmpl.ExecuteTemplate(&sb, "fields", object))
+	require.Equal(t, "Foo;Baz;", sb.String(), `excluded fields should not be visible`)
+}
+
+func TestTemplateTypeByName(t *testing.T) {
+	src := fstest.MapFS{
+		"lookup.tmpl": &fstest.MapFile{
+			Data: []byte(`{{ define "lookup" }}{{ with (typeByName .AllTypes "Shape") }}{{ .GetIsInterface }}{{ else }}none{{ end }}{{ end }}`),
+		},
+	}
+
+	var tt sketch.Template
+	tt.AddFS("/usr", src)
+	tmpl, err := tt.Build()
+	require.NoError(t, err, `Build should succeed`)
+
+	object := &schema.Base{
+		Variables: map[string]interface{}{
+			"DefaultAllTypes": map[string]*schema.TypeSpec{
+				"Shape": schema.TypeName("Shape").IsInterface(true),
+			},
+		},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, tmpl.ExecuteTemplate(&sb, "lookup", object))
+	require.Equal(t, "true", sb.String())
+
+	sb.Reset()
+	require.NoError(t, tmpl.ExecuteTemplate(&sb, "lookup", &schema.Base{}))
+	require.Equal(t, "none", sb.String())
+}
@@ -0,0 +1,88 @@
+// Package uuidvalue provides a storage type for uuid.UUID values from
+// "github.com/google/uuid", which are serialized into JSON as their
+// canonical hyphenated strings.
+//
+// It is used by `schema.UUIDType`. It is a separate module, so that
+// "github.com/google/uuid" is only required by users of `schema.UUIDType`.
+package uuidvalue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// UUID stores a uuid.UUID value
+type UUID struct {
+	u uuid.UUID
+}
+
+// New creates a new UUID from a uuid.UUID value
+func New(u uuid.UUID) *UUID {
+	return &UUID{u: u}
+}
+
+// AcceptValue assigns the value to UUID. The following types are accepted:
+//
+//   - uuid.UUID and *uuid.UUID
+//   - strings in any of the formats accepted by `uuid.Parse`
+//   - nil, which is treated as uuid.Nil
+func (u *UUID) AcceptValue(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		u.u = uuid.Nil
+	case uuid.UUID:
+		u.u = v
+	case *uuid.UUID:
+		if v == nil {
+			u.u = uuid.Nil
+			return nil
+		}
+		u.u = *v
+	case string:
+		parsed, err := uuid.Parse(v)
+		if err != nil {
+			return fmt.Errorf(`failed to parse %q as UUID: %w`, v, err)
+		}
+		u.u = parsed
+	default:
+		return fmt.Errorf(`invalid value for uuidvalue.UUID (got %T)`, v)
+	}
+	return nil
+}
+
+// GetValue returns the uuid.UUID value. uuid.Nil is returned when u is nil.
+func (u *UUID) GetValue() uuid.UUID {
+	if u == nil {
+		return uuid.Nil
+	}
+	return u.u
+}
+
+// Clone returns a copy of u
+func (u *UUID) Clone() *UUID {
+	if u == nil {
+		return nil
+	}
+	return &UUID{u: u.u}
+}
+
+// Equal returns true if both values represent the same UUID
+func (u *UUID) Equal(other *UUID) bool {
+	return u.GetValue() == other.GetValue()
+}
+
+// MarshalJSON encodes the value as a canonical hyphenated string
+func (u UUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.u.String())
+}
+
+// UnmarshalJSON decodes the value using the same rules as `AcceptValue`
+func (u *UUID) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf(`failed to decode uuidvalue.UUID: %w`, err)
+	}
+	return u.AcceptValue(v)
+}
@@ -0,0 +1,53 @@
+package uuidvalue_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/sketch/uuidvalue"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptValue(t *testing.T) {
+	expected := uuid.MustParse(`f47ac10b-58cc-4372-a567-0e02b2c3d479`)
+	testcases := []struct {
+		Name     string
+		Value    interface{}
+		Expected uuid.UUID
+		Error    bool
+	}{
+		{Name: `uuid.UUID`, Value: expected, Expected: expected},
+		{Name: `*uuid.UUID`, Value: &expected, Expected: expected},
+		{Name: `string`, Value: `f47ac10b-58cc-4372-a567-0e02b2c3d479`, Expected: expected},
+		{Name: `urn`, Value: `urn:uuid:f47ac10b-58cc-4372-a567-0e02b2c3d479`, Expected: expected},
+		{Name: `nil`, Value: nil, Expected: uuid.Nil},
+		{Name: `invalid string`, Value: `f47ac10b`, Error: true},
+		{Name: `invalid type`, Value: 1, Error: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			var v uuidvalue.UUID
+			err := v.AcceptValue(tc.Value)
+			if tc.Error {
+				require.Error(t, err, `v.AcceptValue should fail`)
+				return
+			}
+			require.NoError(t, err, `v.AcceptValue should succeed`)
+			require.Equal(t, tc.Expected, v.GetValue(), `values should match`)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	v := uuidvalue.New(uuid.MustParse(`f47ac10b-58cc-4372-a567-0e02b2c3d479`))
+	buf, err := json.Marshal(v)
+	require.NoError(t, err, `json.Marshal should succeed`)
+	require.Equal(t, `"f47ac10b-58cc-4372-a567-0e02b2c3d479"`, string(buf))
+
+	var decoded uuidvalue.UUID
+	require.NoError(t, json.Unmarshal(buf, &decoded), `json.Unmarshal should succeed`)
+	require.True(t, v.Equal(&decoded), `values should match`)
+}